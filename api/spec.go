@@ -0,0 +1,7 @@
+// Package api holds the OpenAPI document describing the HTTP API
+package api
+
+import _ "embed"
+
+//go:embed openapi.yaml
+var Spec []byte
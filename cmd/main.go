@@ -3,16 +3,20 @@ package main
 import (
 	"fmt"
 	"log"
-	"net/http"
-	"time"
+	"net"
+	"os"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sarthak014/Fast-Bank/internal/adapter/grpcapi"
 	"github.com/sarthak014/Fast-Bank/internal/adapter/handler"
+	"github.com/sarthak014/Fast-Bank/internal/adapter/notifier"
+	"github.com/sarthak014/Fast-Bank/internal/adapter/openapi"
 	"github.com/sarthak014/Fast-Bank/internal/adapter/repository"
 	"github.com/sarthak014/Fast-Bank/internal/config"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
 	"github.com/sarthak014/Fast-Bank/internal/core/service"
 	"github.com/sarthak014/Fast-Bank/pkg/utils"
 )
@@ -29,39 +33,126 @@ func main() {
 		log.Fatal(err)
 
 	}
+
+	if len(os.Args) > 1 && os.Args[1] == "rebuild-projections" {
+		n, err := store.RebuildAccountProjection()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("rebuilt %d accounts from the event store", n)
+		return
+	}
+
 	conn, err := repository.NewMQConnection(cfg.AmqConnectionStr)
 	if err != nil {
 		log.Fatal(err)
 	}
-	authService := service.NewAuthService(cfg.JWTSecret)
-	accService := service.NewAccountService(store)
-	trxService := service.NewTransactionService(store, conn)
+	events, err := repository.NewMQEventPublisher(conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	keys, err := service.LoadKeySet(cfg.JWTKeysDir, cfg.JWTSigningKey, cfg.JWTEphemeralKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	notify, err := notifier.New(cfg.Notifier, cfg.NotifierFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	auditService := service.NewAuditService(store)
+	apiKeyService := service.NewAPIKeyService(store)
+	authService := service.NewAuthService(keys, store, apiKeyService, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, cfg.StepUpThreshold, cfg.StepUpMaxAge)
+	hasher := service.NewArgon2Hasher(service.Argon2Params{
+		Memory:  uint32(cfg.Argon2Memory),
+		Time:    uint32(cfg.Argon2Time),
+		Threads: uint8(cfg.Argon2Threads),
+		SaltLen: 16,
+		KeyLen:  32,
+	})
+	passwordPolicy, err := service.LoadPasswordPolicy(cfg.PasswordMinLength, cfg.PasswordMaxLength, cfg.BreachedPasswords)
+	if err != nil {
+		log.Fatalf("Failed to load breached password list: %v", err)
+	}
+	accService := service.NewAccountService(store, store, events, notify, hasher, passwordPolicy, cfg.PasswordResetURL, cfg.EmailVerifyURL)
+	twoFactorService := service.NewTwoFactorService(store)
+	loginGuard := service.NewLoginGuard(store, cfg.LoginMaxFailures, cfg.LoginLockout)
+	trxService := service.NewTransactionService(store, conn, events, auditService)
+
+	// ADMIN_ACCOUNTS bootstraps the first admins, further roles are granted through the API
+	for _, accNo := range cfg.AdminAccounts {
+		acc, err := accService.GetByAccNo(accNo)
+		if err == nil && acc.Role != domain.RoleAdmin {
+			_, err = accService.SetRole(accNo, domain.RoleAdmin)
+		}
+		if err != nil {
+			log.Printf("could not grant admin role to account %d: %v", accNo, err)
+		}
+	}
+
+	h := handler.NewApiHandler(accService, trxService, authService, auditService, twoFactorService, loginGuard, apiKeyService)
 
-	h := handler.NewApiHandler(accService, trxService, authService)
+	spec, err := openapi.New(cfg.ValidateResponses)
+	if err != nil {
+		log.Fatalf("Failed to load the OpenAPI document: %v", err)
+	}
 
 	e := echo.New()
+	e.HTTPErrorHandler = handler.ErrorHandler
+	// the client IP keys login throttling, rate limits and the audit log, so
+	// X-Forwarded-For is only believed when it comes from a trusted proxy
+	e.IPExtractor = echo.ExtractIPDirect()
+	if len(cfg.TrustedProxies) > 0 {
+		trust := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+		for _, ipNet := range cfg.TrustedProxies {
+			trust = append(trust, echo.TrustIPRange(ipNet))
+		}
+		e.IPExtractor = echo.ExtractIPFromXFFHeader(trust...)
+	}
+	e.Validator = handler.NewValidator()
+	e.Use(middleware.RequestID())
 	e.Use(utils.CustomLogger(httpRequestsTotal))
 	e.Use(middleware.Recover())
+	e.Use(spec.Middleware)
 
-	e.GET("/", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, map[string]string{"msg": "works", "time": time.Now().UTC().String()})
-	})
-	e.GET("/account", h.HandleGetAccount)
-	e.POST("/account", h.HandleCreateAccount)
-	e.POST("/login", h.HandleLogin)
-
-	jwtGroup := e.Group("")
-	jwtGroup.Use(h.AuthService.Middleware)
-	jwtGroup.GET("/jwt", h.JwtRoute)
-	jwtGroup.GET("/account/:id", h.HandleGetAccountById)
-	jwtGroup.DELETE("/account/:id", h.HandleDeleteAccount)
-	jwtGroup.POST("/transfer/:accno", h.HandleTransfer)
-	jwtGroup.GET("/transfer/:id", h.GetTransferStatus)
-	jwtGroup.GET("/transfer", h.GetTrxByAcc)
+	// postgres shares the counters between instances, memory is per instance
+	var rateLimitStore port.RateLimitStore
+	switch cfg.RateLimitStore {
+	case "memory":
+		rateLimitStore = repository.NewMemoryRateLimitStore()
+	case "postgres":
+		rateLimitStore = store
+	default:
+		log.Fatalf("unknown RATE_LIMIT_STORE %q, use memory or postgres", cfg.RateLimitStore)
+	}
+	limiter := service.NewRateLimiter(rateLimitStore)
+	limits := rateLimits{
+		ip:       limiter.Limit(cfg.RateLimitIP),
+		auth:     limiter.Limit(cfg.RateLimitAuth),
+		signup:   limiter.Limit(cfg.RateLimitSignup),
+		transfer: limiter.Limit(cfg.RateLimitTransfer),
+		standard: limiter.Limit(cfg.RateLimitDefault),
+	}
+
+	registerRoutes(e, h, spec, limits)
 	e.HideBanner = true
-	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+
+	// every route has to be in api/openapi.yaml, refuse to start otherwise
+	if err := spec.CheckRoutes(e.Routes()); err != nil {
+		log.Fatal(err)
+	}
+
+	// internal services talk gRPC, on its own port next to the HTTP API
+	grpcServer := grpcapi.NewServer(accService, trxService, authService, auditService)
+	lis, err := net.Listen("tcp", cfg.GRPCPort)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		log.Fatal(grpcServer.Serve(lis))
+	}()
 
 	go h.TransactionService.ProcessTransfers()
+	go h.TransactionService.ListenTransferStatus()
 	fmt.Println("\033[32m",
 		`________  ________  ________   ___  ___      
 |\   __  \|\   __  \|\   ___  \|\  \|\  \     
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sarthak014/Fast-Bank/internal/adapter/handler"
+	"github.com/sarthak014/Fast-Bank/internal/adapter/openapi"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// deprecations announced so far, a route keeps working until its sunset
+var (
+	// unversioned routes predate /v1 and are aliases of it
+	legacyDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	legacySunset     = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+	// /v2 takes the recipient in the body and returns the whole transfer
+	v1TransferDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	v1TransferSunset     = time.Date(2027, time.October, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// rateLimits holds one middleware per limit, shared by all versions so a
+// route counts the same whether it is called through /v1 or its alias
+type rateLimits struct {
+	// ip runs before authentication, so callers with bad credentials are
+	// limited too
+	ip       echo.MiddlewareFunc
+	auth     echo.MiddlewareFunc
+	signup   echo.MiddlewareFunc
+	transfer echo.MiddlewareFunc
+	standard echo.MiddlewareFunc
+}
+
+// registerRoutes adds every route of the HTTP API to e
+func registerRoutes(e *echo.Echo, h *handler.ApiHandler, spec *openapi.Validator, limits rateLimits) {
+	e.GET("/", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"msg": "works", "time": time.Now().UTC().String()})
+	})
+	e.GET("/.well-known/jwks.json", h.HandleJWKS)
+	e.GET("/openapi.json", spec.HandleSpec)
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+
+	registerV1(e.Group("/v1"), h, limits)
+	registerV2(e.Group("/v2"), h, limits)
+	registerV1(e.Group("", handler.Deprecated(legacyDeprecated, legacySunset, "/v1")), h, limits)
+}
+
+// registerV1 adds the v1 API to g, it is mounted at /v1 and, deprecated, at the root
+func registerV1(g *echo.Group, h *handler.ApiHandler, limits rateLimits) {
+	g.POST("/account", h.HandleCreateAccount, limits.signup)
+	g.POST("/login", h.HandleLogin, limits.auth)
+	g.POST("/login/2fa", h.HandleLoginChallenge, limits.auth)
+	g.POST("/token/refresh", h.HandleRefresh, limits.auth)
+	g.POST("/password/forgot", h.HandleForgotPassword, limits.auth)
+	g.POST("/password/reset", h.HandleResetPassword, limits.auth)
+	g.POST("/email/verify", h.HandleVerifyEmail, limits.auth)
+
+	jwtGroup := g.Group("")
+	jwtGroup.Use(limits.ip, h.AuthService.Middleware)
+	// routes open to API keys declare the scope they need, the others need a user session
+	session := h.AuthService.RequireSession
+	scope := h.AuthService.RequireScope
+	transferDeprecated := handler.Deprecated(v1TransferDeprecated, v1TransferSunset, "/v2/transfer")
+	jwtGroup.POST("/login/step-up", h.HandleStepUp, session, limits.auth)
+	jwtGroup.POST("/password/change", h.HandleChangePassword, session, limits.auth)
+	jwtGroup.POST("/email/verify/resend", h.HandleResendEmailVerification, session, limits.auth)
+	jwtGroup.POST("/transfer/:accno", h.HandleTransfer, scope(domain.ScopeTransfersWrite), limits.transfer, transferDeprecated)
+
+	// everything without a limit of its own
+	api := jwtGroup.Group("", limits.standard)
+	api.GET("/jwt", h.JwtRoute, session)
+	api.GET("/account", h.HandleGetAccount, h.AuthService.RequireRole(domain.RoleSupport, domain.RoleAdmin))
+	api.POST("/logout", h.HandleLogout, session)
+	api.POST("/2fa/enroll", h.HandleEnrollTOTP, session)
+	api.POST("/2fa/verify", h.HandleActivateTOTP, session)
+	api.POST("/apikeys", h.HandleCreateAPIKey, session)
+	api.GET("/apikeys", h.HandleListAPIKeys, session)
+	api.DELETE("/apikeys/:id", h.HandleRevokeAPIKey, session)
+	api.GET("/account/:id", h.HandleGetAccountById, scope(domain.ScopeAccountsRead))
+	api.DELETE("/account/:id", h.HandleDeleteAccount, session)
+	api.GET("/account/:id/balance", h.HandleGetBalance, scope(domain.ScopeAccountsRead))
+	api.GET("/account/:id/history", h.HandleGetHistory, scope(domain.ScopeTransfersRead))
+	api.GET("/transfer/:id", h.GetTransferStatus, scope(domain.ScopeTransfersRead), transferDeprecated)
+	api.GET("/transfer/:id/events", h.HandleTransferEvents, scope(domain.ScopeTransfersRead))
+	api.GET("/transfer", h.GetTrxByAcc, scope(domain.ScopeTransfersRead))
+
+	// support staff can look accounts up, changing them stays with admins
+	staffGroup := api.Group("/admin", h.AuthService.RequireRole(domain.RoleSupport, domain.RoleAdmin))
+	staffGroup.GET("/account", h.HandleSearchAccounts)
+	staffGroup.GET("/account/:id", h.HandleGetAccountDetail)
+	staffGroup.GET("/account/:id/transfers", h.HandleGetAccountTransfers)
+
+	adminGroup := api.Group("/admin", h.AuthService.RequireRole(domain.RoleAdmin))
+	adminGroup.GET("/audit", h.HandleGetAudit)
+	adminGroup.PUT("/account/:id/role", h.HandleSetRole)
+	adminGroup.PUT("/account/:id/status", h.HandleSetStatus)
+	adminGroup.POST("/account/:id/close", h.HandleCloseAccount)
+	adminGroup.POST("/account/:id/unlock", h.HandleUnlockAccount)
+}
+
+// registerV2 only holds the routes whose payloads changed, everything else
+// is still served by /v1
+func registerV2(g *echo.Group, h *handler.ApiHandler, limits rateLimits) {
+	jwtGroup := g.Group("")
+	jwtGroup.Use(limits.ip, h.AuthService.Middleware)
+	scope := h.AuthService.RequireScope
+	jwtGroup.POST("/transfer", h.HandleTransferV2, scope(domain.ScopeTransfersWrite), limits.transfer)
+	jwtGroup.GET("/transfer/:id", h.HandleGetTransferV2, scope(domain.ScopeTransfersRead), limits.standard)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/adapter/handler"
+	"github.com/sarthak014/Fast-Bank/internal/adapter/openapi"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+)
+
+// routeAuth only provides the middlewares the routes are registered with
+type routeAuth struct{ port.AuthService }
+
+func (routeAuth) Middleware(next echo.HandlerFunc) echo.HandlerFunc     { return next }
+func (routeAuth) RequireSession(next echo.HandlerFunc) echo.HandlerFunc { return next }
+func (routeAuth) RequireRole(...string) echo.MiddlewareFunc             { return passThrough }
+func (routeAuth) RequireScope(string) echo.MiddlewareFunc               { return passThrough }
+
+func passThrough(next echo.HandlerFunc) echo.HandlerFunc { return next }
+
+// every route has to be documented in api/openapi.yaml and the other way round
+func TestRoutesMatchSpec(t *testing.T) {
+	spec, err := openapi.New(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := echo.New()
+	h := &handler.ApiHandler{AuthService: routeAuth{}}
+	registerRoutes(e, h, spec, rateLimits{passThrough, passThrough, passThrough, passThrough, passThrough})
+
+	if err := spec.CheckRoutes(e.Routes()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,119 @@
+package grpcapi
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/api/gen/fastbank/v1"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type accountServer struct {
+	fastbankv1.UnimplementedAccountServiceServer
+	accounts port.AccountService
+	audit    port.AuditService
+}
+
+func (s *accountServer) GetAccount(ctx context.Context, req *fastbankv1.GetAccountRequest) (*fastbankv1.GetAccountResponse, error) {
+	claims, err := claimsFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := s.accounts.GetByAccNo(int(req.AcNumber))
+	if err != nil {
+		return nil, err
+	}
+	if int(req.AcNumber) != claims.Id {
+		if !claims.HasRole(domain.RoleSupport, domain.RoleAdmin) {
+			return &fastbankv1.GetAccountResponse{Account: &fastbankv1.GetAccountResponse_Masked{Masked: toMaskedAccount(acc)}}, nil
+		}
+		s.audit.Record(auditEntry(ctx, claims, domain.AuditAccountViewed, "account", strconv.Itoa(int(req.AcNumber))), nil, nil)
+	}
+	return &fastbankv1.GetAccountResponse{Account: &fastbankv1.GetAccountResponse_Full{Full: toAccount(acc)}}, nil
+}
+
+func (s *accountServer) ListAccounts(ctx context.Context, req *fastbankv1.ListAccountsRequest) (*fastbankv1.ListAccountsResponse, error) {
+	claims, err := claimsFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	verr := &domain.ValidationError{}
+	filter := domain.AccountFilter{
+		Role:       oneOf(verr, "role", req.Role, domain.RoleCustomer, domain.RoleSupport, domain.RoleAdmin),
+		Status:     oneOf(verr, "status", req.Status, domain.StatusActive, domain.StatusFrozen, domain.StatusDebitBlocked, domain.StatusClosed),
+		MinBalance: req.MinBalance,
+		MaxBalance: req.MaxBalance,
+		From:       timeOf(req.CreatedAfter),
+		To:         timeOf(req.CreatedBefore),
+		Sort:       orderBy(verr, req.OrderBy, domain.AccountSortFields...),
+		Page:       page(verr, req.PageSize, req.PageToken),
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	accs, next, err := s.accounts.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	res := &fastbankv1.ListAccountsResponse{Accounts: make([]*fastbankv1.Account, 0, len(accs)), NextPageToken: next}
+	found := make([]int32, 0, len(accs))
+	for _, acc := range accs {
+		res.Accounts = append(res.Accounts, toAccount(acc))
+		found = append(found, acc.AcNumber)
+	}
+	// only staff get here, every list they see is audited like a search
+	s.audit.Record(auditEntry(ctx, claims, domain.AuditAccountSearched, "account", ""), nil, map[string]any{
+		"role":        filter.Role,
+		"status":      filter.Status,
+		"min_balance": filter.MinBalance,
+		"max_balance": filter.MaxBalance,
+		"from":        filter.From,
+		"to":          filter.To,
+		"cursor":      filter.Cursor,
+		"results":     found,
+	})
+	return res, nil
+}
+
+func (s *accountServer) GetBalance(ctx context.Context, req *fastbankv1.GetBalanceRequest) (*fastbankv1.Balance, error) {
+	claims, err := claimsFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if int(req.AcNumber) != claims.Id {
+		return nil, status.Error(codes.PermissionDenied, "not your account")
+	}
+	at := time.Now().UTC()
+	if req.At != nil {
+		at = req.At.AsTime()
+	}
+	bal, err := s.accounts.BalanceAt(claims.Id, at)
+	if err != nil {
+		return nil, err
+	}
+	return &fastbankv1.Balance{AcNumber: bal.AcNumber, Balance: bal.Balance, At: timestamppb.New(bal.At)}, nil
+}
+
+func toAccount(acc *domain.Account) *fastbankv1.Account {
+	return &fastbankv1.Account{
+		AcNumber:      acc.AcNumber,
+		Fname:         acc.Fname,
+		Lname:         acc.Lname,
+		Email:         acc.Email,
+		Balance:       acc.Balance,
+		Role:          acc.Role,
+		CreatedAt:     timestamppb.New(acc.CreatedAt),
+		EmailVerified: acc.EmailVerifiedAt != nil,
+		Status:        acc.Status,
+	}
+}
+
+func toMaskedAccount(acc *domain.Account) *fastbankv1.MaskedAccount {
+	return &fastbankv1.MaskedAccount{AcNumber: acc.AcNumber, Name: acc.MaskedName(), Email: acc.MaskedEmail()}
+}
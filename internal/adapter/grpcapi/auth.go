@@ -0,0 +1,112 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sarthak014/Fast-Bank/api/gen/fastbank/v1"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// access is what a method requires of its caller, the gRPC counterpart of
+// RequireScope and RequireRole on the HTTP routes
+type access struct {
+	scope string
+	roles []string
+}
+
+// methods lists every RPC, a method missing here is refused
+var methods = map[string]access{
+	fastbankv1.AccountService_GetAccount_FullMethodName:         {scope: domain.ScopeAccountsRead},
+	fastbankv1.AccountService_ListAccounts_FullMethodName:       {roles: []string{domain.RoleSupport, domain.RoleAdmin}},
+	fastbankv1.AccountService_GetBalance_FullMethodName:         {scope: domain.ScopeAccountsRead},
+	fastbankv1.TransactionService_CreateTransfer_FullMethodName: {scope: domain.ScopeTransfersWrite},
+	fastbankv1.TransactionService_GetTransfer_FullMethodName:    {scope: domain.ScopeTransfersRead},
+	fastbankv1.TransactionService_ListTransfers_FullMethodName:  {scope: domain.ScopeTransfersRead},
+	fastbankv1.TransactionService_ListHistory_FullMethodName:    {scope: domain.ScopeTransfersRead},
+	fastbankv1.TransactionService_WatchTransfer_FullMethodName:  {scope: domain.ScopeTransfersRead},
+}
+
+type claimsKey struct{}
+
+func claimsFrom(ctx context.Context) (*domain.JWTClaims, error) {
+	claims, ok := ctx.Value(claimsKey{}).(*domain.JWTClaims)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	return claims, nil
+}
+
+type authInterceptor struct {
+	auth port.AuthService
+}
+
+func (a *authInterceptor) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	res, err := handler(ctx, req)
+	return res, toStatus(info.FullMethod, err)
+}
+
+func (a *authInterceptor) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return toStatus(info.FullMethod, handler(srv, &authedStream{ServerStream: ss, ctx: ctx}))
+}
+
+// authorize reads the credential the same way the HTTP middleware does, a
+// bearer token in authorization or an API key in x-api-key
+func (a *authInterceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	rule, ok := methods[method]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "unknown method")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	credential, ok := strings.CutPrefix(first(md, "authorization"), "Bearer ")
+	if key := first(md, "x-api-key"); key != "" {
+		credential, ok = key, true
+	}
+	if !ok || credential == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	claims, err := a.auth.Authenticate(credential)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	if rule.scope != "" && !claims.HasScope(rule.scope) {
+		return nil, status.Error(codes.PermissionDenied, "missing scope "+rule.scope)
+	}
+	if len(rule.roles) > 0 && !claims.HasRole(rule.roles...) {
+		return nil, status.Error(codes.PermissionDenied, "forbidden")
+	}
+	return context.WithValue(ctx, claimsKey{}, claims), nil
+}
+
+func first(md metadata.MD, key string) string {
+	if vals := md.Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// authedStream hands the claims to streaming handlers, a ServerStream's
+// context cannot be replaced otherwise
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,76 @@
+package grpcapi
+
+import (
+	"errors"
+	"log"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// toStatus maps domain errors to gRPC codes like ErrorHandler maps them to
+// HTTP statuses, errors that already are a status pass through
+func toStatus(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var (
+		validation *domain.ValidationError
+		policy     *domain.PasswordPolicyError
+		credential *domain.CredentialError
+		stepUp     *domain.StepUpRequiredError
+		blocked    *domain.LoginBlockedError
+	)
+	switch {
+	case errors.As(err, &validation):
+		br := &errdetails.BadRequest{}
+		for _, f := range validation.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message})
+		}
+		return withDetails(status.New(codes.InvalidArgument, "the request has invalid fields"), br)
+	case errors.As(err, &policy):
+		return status.Error(codes.InvalidArgument, policy.Error())
+	case errors.As(err, &credential):
+		return status.Error(codes.InvalidArgument, credential.Error())
+	case errors.As(err, &stepUp):
+		return status.Error(codes.Unauthenticated, stepUp.Error())
+	case errors.As(err, &blocked):
+		return withDetails(status.New(codes.ResourceExhausted, blocked.Error()), &errdetails.RetryInfo{RetryDelay: durationpb.New(blocked.RetryAfter)})
+	case errors.Is(err, domain.ErrInsufficientBalance), errors.Is(err, domain.ErrAccountFrozen),
+		errors.Is(err, domain.ErrAccountDebitBlocked), errors.Is(err, domain.ErrAccountClosed),
+		errors.Is(err, domain.ErrBalanceNotZero), errors.Is(err, domain.ErrTwoFactorEnabled),
+		errors.Is(err, domain.ErrTwoFactorNotEnabled), errors.Is(err, domain.ErrNoEnrollment):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrInvalidEmail):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, domain.ErrEmailNotVerified):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		// never leak internal errors to the client
+		log.Printf("Error handling %s: %v", method, err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	if detailed, err := st.WithDetails(details...); err == nil {
+		return detailed.Err()
+	}
+	return st.Err()
+}
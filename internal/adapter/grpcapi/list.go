@@ -0,0 +1,53 @@
+package grpcapi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// the list RPCs take the same filters as the HTTP query parameters, problems
+// are collected in verr and reported together
+
+func page(verr *domain.ValidationError, size int32, token string) domain.Page {
+	if size < 0 || size > domain.MaxPageSize {
+		verr.Add("page_size", fmt.Sprintf("must be between 1 and %d", domain.MaxPageSize))
+	}
+	if size == 0 {
+		size = domain.DefaultPageSize
+	}
+	return domain.Page{Limit: int(size), Cursor: token}
+}
+
+func orderBy(verr *domain.ValidationError, v string, fields ...string) domain.Sort {
+	if v == "" {
+		v = "-created_at"
+	}
+	sort, ok := domain.ParseSort(v, fields...)
+	if !ok {
+		verr.Add("order_by", fmt.Sprintf("must be one of %v, prefixed with - for descending order", fields))
+	}
+	return sort
+}
+
+func oneOf(verr *domain.ValidationError, name, v string, allowed ...string) string {
+	if v == "" {
+		return ""
+	}
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	verr.Add(name, fmt.Sprintf("must be one of %v", allowed))
+	return v
+}
+
+func timeOf(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
@@ -0,0 +1,20 @@
+package grpcapi
+
+import (
+	"github.com/sarthak014/Fast-Bank/api/gen/fastbank/v1"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+	"google.golang.org/grpc"
+)
+
+// NewServer exposes the account and transaction services over gRPC, next to
+// the HTTP API and with the same authentication and authorization rules
+func NewServer(accounts port.AccountService, transactions port.TransactionService, auth port.AuthService, audit port.AuditService) *grpc.Server {
+	a := &authInterceptor{auth: auth}
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(a.unary),
+		grpc.ChainStreamInterceptor(a.stream),
+	)
+	fastbankv1.RegisterAccountServiceServer(srv, &accountServer{accounts: accounts, audit: audit})
+	fastbankv1.RegisterTransactionServiceServer(srv, &transactionServer{transactions: transactions, auth: auth, audit: audit})
+	return srv
+}
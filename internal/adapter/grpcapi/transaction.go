@@ -0,0 +1,206 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+
+	"github.com/sarthak014/Fast-Bank/api/gen/fastbank/v1"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type transactionServer struct {
+	fastbankv1.UnimplementedTransactionServiceServer
+	transactions port.TransactionService
+	auth         port.AuthService
+	audit        port.AuditService
+}
+
+func (s *transactionServer) CreateTransfer(ctx context.Context, req *fastbankv1.CreateTransferRequest) (*fastbankv1.Transfer, error) {
+	claims, err := claimsFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateTransfer(claims.Id, int(req.ToAccount), req.Amount); err != nil {
+		return nil, err
+	}
+	if err := s.auth.CheckStepUp(claims, req.Amount); err != nil {
+		return nil, err
+	}
+	trx, err := s.transactions.CreateTransfer(claims.Id, int(req.ToAccount), req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(auditEntry(ctx, claims, domain.AuditTransferCreated, "transfer", trx.TransferId), nil, trx)
+	return toTransfer(trx), nil
+}
+
+func (s *transactionServer) GetTransfer(ctx context.Context, req *fastbankv1.GetTransferRequest) (*fastbankv1.Transfer, error) {
+	claims, err := claimsFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trx, err := s.partyTransfer(claims, req.TransferId)
+	if err != nil {
+		return nil, err
+	}
+	return toTransfer(trx), nil
+}
+
+func (s *transactionServer) ListTransfers(ctx context.Context, req *fastbankv1.ListTransfersRequest) (*fastbankv1.ListTransfersResponse, error) {
+	claims, err := claimsFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	verr := &domain.ValidationError{}
+	filter := domain.TransferFilter{
+		AccNo:        claims.Id,
+		Direction:    oneOf(verr, "direction", req.Direction, domain.DirectionSent, domain.DirectionReceived),
+		Counterparty: int(req.Counterparty),
+		Status:       oneOf(verr, "status", req.Status, domain.TransferPending, domain.TransferCompleted, domain.TransferFailed),
+		MinAmount:    req.MinAmount,
+		MaxAmount:    req.MaxAmount,
+		From:         timeOf(req.CreatedAfter),
+		To:           timeOf(req.CreatedBefore),
+		Sort:         orderBy(verr, req.OrderBy, domain.TransferSortFields...),
+		Page:         page(verr, req.PageSize, req.PageToken),
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	trxs, next, err := s.transactions.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	res := &fastbankv1.ListTransfersResponse{Transfers: make([]*fastbankv1.Transfer, 0, len(trxs)), NextPageToken: next}
+	for _, trx := range trxs {
+		res.Transfers = append(res.Transfers, toTransfer(trx))
+	}
+	return res, nil
+}
+
+func (s *transactionServer) ListHistory(ctx context.Context, req *fastbankv1.ListHistoryRequest) (*fastbankv1.ListHistoryResponse, error) {
+	claims, err := claimsFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	verr := &domain.ValidationError{}
+	filter := domain.HistoryFilter{
+		AccNo:        claims.Id,
+		Direction:    oneOf(verr, "direction", req.Direction, domain.DirectionSent, domain.DirectionReceived),
+		Counterparty: int(req.Counterparty),
+		From:         timeOf(req.CreatedAfter),
+		To:           timeOf(req.CreatedBefore),
+		Sort:         orderBy(verr, req.OrderBy, domain.HistorySortFields...),
+		Page:         page(verr, req.PageSize, req.PageToken),
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	entries, next, err := s.transactions.History(filter)
+	if err != nil {
+		return nil, err
+	}
+	res := &fastbankv1.ListHistoryResponse{Entries: make([]*fastbankv1.HistoryEntry, 0, len(entries)), NextPageToken: next}
+	for _, e := range entries {
+		res.Entries = append(res.Entries, &fastbankv1.HistoryEntry{
+			TransferId:   e.TransferId,
+			Direction:    e.Direction,
+			Counterparty: int32(e.Counterparty),
+			Amount:       e.Amount,
+			Balance:      e.Balance,
+			CreatedAt:    timestamppb.New(e.CreatedAt),
+		})
+	}
+	return res, nil
+}
+
+func (s *transactionServer) WatchTransfer(req *fastbankv1.WatchTransferRequest, stream fastbankv1.TransactionService_WatchTransferServer) error {
+	claims, err := claimsFrom(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	// subscribe before reading the current state so no update is missed in between
+	events, cancel := s.transactions.WatchTransfer(req.TransferId)
+	defer cancel()
+
+	trx, err := s.partyTransfer(claims, req.TransferId)
+	if err != nil {
+		return err
+	}
+	current := domain.TransferStatusEvent{TransferId: trx.TransferId, Status: trx.Status, UpdatedAt: trx.UpdatedAt}
+	if err := stream.Send(toStatusEvent(current)); err != nil || current.Final() {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case evt := <-events:
+			if err := stream.Send(toStatusEvent(evt)); err != nil || evt.Final() {
+				return err
+			}
+		}
+	}
+}
+
+// partyTransfer hides transfers the caller did not send or receive
+func (s *transactionServer) partyTransfer(claims *domain.JWTClaims, id string) (*domain.TransferMessage, error) {
+	trx, err := s.transactions.GetTransfer(id)
+	if err != nil {
+		return nil, err
+	}
+	if trx.SenderId != claims.Id && trx.ToAccount != claims.Id {
+		return nil, status.Error(codes.NotFound, "transfer not found")
+	}
+	return trx, nil
+}
+
+func auditEntry(ctx context.Context, claims *domain.JWTClaims, action, resource, resourceId string) domain.AuditEntry {
+	entry := domain.AuditEntry{
+		ActorId:    claims.Id,
+		Action:     action,
+		Resource:   resource,
+		ResourceId: resourceId,
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		entry.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(entry.IP); err == nil {
+			entry.IP = host
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		entry.RequestId = first(md, "x-request-id")
+	}
+	return entry
+}
+
+func toTransfer(trx *domain.TransferMessage) *fastbankv1.Transfer {
+	return &fastbankv1.Transfer{
+		TransferId:  trx.TransferId,
+		FromAccount: int32(trx.SenderId),
+		ToAccount:   int32(trx.ToAccount),
+		Amount:      trx.Amount,
+		Status:      trx.Status,
+		CreatedAt:   timestamppb.New(trx.CreatedAt),
+		UpdatedAt:   timestamppb.New(trx.UpdatedAt),
+	}
+}
+
+func toStatusEvent(evt domain.TransferStatusEvent) *fastbankv1.TransferStatusEvent {
+	return &fastbankv1.TransferStatusEvent{
+		TransferId: evt.TransferId,
+		Status:     evt.Status,
+		Reason:     evt.Reason,
+		UpdatedAt:  timestamppb.New(evt.UpdatedAt),
+	}
+}
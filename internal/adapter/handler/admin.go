@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// the account detail shows this many of the latest transfers and logins,
+// the full transfer list is paginated under /admin/account/:id/transfers
+const recentItems = 10
+
+var accountStatuses = []string{domain.StatusActive, domain.StatusFrozen, domain.StatusDebitBlocked, domain.StatusClosed}
+
+var loginActions = []string{domain.AuditLogin, domain.AuditLoginFailed, domain.AuditLoginChallenged}
+
+// HandleSearchAccounts finds accounts for support staff, every search is
+// audited with its criteria and the accounts it returned
+func (s *ApiHandler) HandleSearchAccounts(c echo.Context) error {
+	q := newListQuery(c)
+	filter := domain.AccountFilter{
+		Name:        strings.TrimSpace(c.QueryParam("name")),
+		EmailPrefix: strings.TrimSpace(c.QueryParam("email")),
+		AcNumber:    q.int("ac_number"),
+		Role:        q.oneOf("role", domain.RoleCustomer, domain.RoleSupport, domain.RoleAdmin),
+		Status:      q.oneOf("status", accountStatuses...),
+		Sort:        q.sort("ac_number", domain.AccountSortFields...),
+		Page:        q.page(),
+	}
+	if filter.Name == "" && filter.EmailPrefix == "" && filter.AcNumber == 0 {
+		q.verr.Add("name", "name, email or ac_number is required")
+	}
+	if err := q.verr.Err(); err != nil {
+		return err
+	}
+
+	accounts, next, err := s.AccountService.List(filter)
+	if err != nil {
+		return err
+	}
+	s.AuditService.Record(auditEntry(c, domain.AuditAccountSearched, "account", ""), nil, map[string]any{
+		"name":      filter.Name,
+		"email":     filter.EmailPrefix,
+		"ac_number": filter.AcNumber,
+		"cursor":    filter.Cursor,
+		"results":   accountNumbers(accounts),
+	})
+	setNextLink(c, next)
+	return c.JSON(http.StatusOK, toAccountResponses(accounts))
+}
+
+func accountNumbers(accounts []*domain.Account) []int32 {
+	accNos := make([]int32, 0, len(accounts))
+	for _, acc := range accounts {
+		accNos = append(accNos, acc.AcNumber)
+	}
+	return accNos
+}
+
+// HandleGetAccountDetail shows an account with its latest transfers and
+// logins and whatever currently holds it back
+func (s *ApiHandler) HandleGetAccountDetail(c echo.Context) error {
+	accNo, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return domain.NewValidationError("id", "must be an account number")
+	}
+	acc, err := s.AccountService.GetByAccNo(accNo)
+	if err != nil {
+		return err
+	}
+
+	recent, _, err := s.TransactionService.List(domain.TransferFilter{
+		AccNo: accNo,
+		Sort:  domain.Sort{Field: "created_at", Desc: true},
+		Page:  domain.Page{Limit: recentItems},
+	})
+	if err != nil {
+		return err
+	}
+	pending, _, err := s.TransactionService.List(domain.TransferFilter{
+		AccNo:     accNo,
+		Direction: domain.DirectionSent,
+		Status:    domain.TransferPending,
+		Sort:      domain.Sort{Field: "created_at"},
+		Page:      domain.Page{Limit: domain.MaxPageSize},
+	})
+	if err != nil {
+		return err
+	}
+	logins, err := s.AuditService.Query(domain.AuditFilter{
+		Actions:    loginActions,
+		Resource:   "account",
+		ResourceId: c.Param("id"),
+		Limit:      recentItems,
+	})
+	if err != nil {
+		return err
+	}
+	lockout, err := s.LoginGuard.Lockout(accNo)
+	if err != nil {
+		return err
+	}
+
+	s.AuditService.Record(auditEntry(c, domain.AuditAccountViewed, "account", c.Param("id")), nil, nil)
+	return c.JSON(http.StatusOK, AccountDetailResponse{
+		Account:         toAccountResponse(acc),
+		StatusReason:    acc.StatusReason,
+		RecentTransfers: toTransferResponses(recent),
+		Logins:          toLoginResponses(logins),
+		Holds: HoldsResponse{
+			PendingTransfers: toTransferResponses(pending),
+			LoginLockout:     toLoginLockoutResponse(lockout),
+		},
+	})
+}
+
+// HandleGetAccountTransfers pages through the transfers of any account,
+// with the filters of GET /transfer
+func (s *ApiHandler) HandleGetAccountTransfers(c echo.Context) error {
+	accNo, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return domain.NewValidationError("id", "must be an account number")
+	}
+	q := newListQuery(c)
+	filter := q.transferFilter(accNo)
+	if err := q.verr.Err(); err != nil {
+		return err
+	}
+	if _, err := s.AccountService.GetByAccNo(accNo); err != nil {
+		return err
+	}
+
+	trxs, next, err := s.TransactionService.List(filter)
+	if err != nil {
+		return err
+	}
+	s.AuditService.Record(auditEntry(c, domain.AuditAccountViewed, "account", c.Param("id")), nil,
+		map[string]string{"view": "transfers", "cursor": filter.Cursor})
+	setNextLink(c, next)
+	return c.JSON(http.StatusOK, toTransferResponses(trxs))
+}
+
+func (s *ApiHandler) HandleSetStatus(c echo.Context) error {
+	accNo, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return domain.NewValidationError("id", "must be an account number")
+	}
+	req := new(domain.SetStatusReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+
+	before, err := s.AccountService.GetByAccNo(accNo)
+	if err != nil {
+		return err
+	}
+	acc, err := s.AccountService.SetStatus(accNo, req.Status, req.Reason)
+	if err != nil {
+		return err
+	}
+	s.AuditService.Record(auditEntry(c, domain.AuditStatusChanged, "account", c.Param("id")),
+		domain.StatusChangedData{Status: before.Status, Reason: before.StatusReason},
+		domain.StatusChangedData{Status: acc.Status, Reason: acc.StatusReason})
+	return c.JSON(http.StatusOK, toAccountResponse(acc))
+}
+
+func (s *ApiHandler) HandleCloseAccount(c echo.Context) error {
+	accNo, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return domain.NewValidationError("id", "must be an account number")
+	}
+	req := new(domain.CloseAccountReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+	res, err := s.closeAccount(c, domain.AuditAccountClosed, accNo, req.TransferTo, req.Reason)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// closeAccount closes the account, audits it and ends every session and API
+// key of its owner since a closed account cannot log in anymore
+func (s *ApiHandler) closeAccount(c echo.Context, action string, accNo, transferTo int, reason string) (*CloseAccountResponse, error) {
+	before, err := s.AccountService.GetByAccNo(accNo)
+	if err != nil {
+		return nil, err
+	}
+	// moving the balance away is a transfer of the owner, it needs the same
+	// checks as one, admins closing somebody else's account act on their own
+	if claims, ok := c.Get("user").(*domain.JWTClaims); ok && transferTo != 0 && claims.Id == accNo {
+		if err := before.CanSendTransfers(); err != nil {
+			return nil, err
+		}
+		if err := s.AuthService.CheckStepUp(claims, before.Balance); err != nil {
+			return nil, err
+		}
+	}
+	acc, sweep, err := s.AccountService.Close(accNo, transferTo, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	after := map[string]any{"status": acc.Status, "reason": acc.StatusReason}
+	res := &CloseAccountResponse{Account: toAccountResponse(acc)}
+	if sweep != nil {
+		after["transfer"] = sweep
+		trx := toTransferResponse(sweep)
+		res.Transfer = &trx
+	}
+	s.AuditService.Record(auditEntry(c, action, "account", c.Param("id")),
+		map[string]any{"account": accountSnapshot(before), "status": before.Status, "balance": before.Balance}, after)
+
+	if err := s.AuthService.RevokeAllSessions(accNo); err != nil {
+		log.Printf("Error revoking the sessions of closed account %d: %v", accNo, err)
+	}
+	keys, err := s.APIKeyService.List(accNo)
+	if err != nil {
+		log.Printf("Error listing the api keys of closed account %d: %v", accNo, err)
+	}
+	for _, key := range keys {
+		if key.RevokedAt != nil {
+			continue
+		}
+		if err := s.APIKeyService.Revoke(accNo, key.Id); err != nil {
+			log.Printf("Error revoking api key %s of closed account %d: %v", key.Id, accNo, err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+func (s *ApiHandler) HandleCreateAPIKey(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	req := new(domain.CreateAPIKeyReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+
+	key, plain, err := s.APIKeyService.Create(claims.Id, req)
+	if err != nil {
+		return err
+	}
+	s.AuditService.Record(auditEntry(c, domain.AuditAPIKeyCreated, "api_key", key.Id), nil, toAPIKeyResponse(key))
+	return c.JSON(http.StatusCreated, CreatedAPIKeyResponse{APIKeyResponse: toAPIKeyResponse(key), Key: plain})
+}
+
+func (s *ApiHandler) HandleListAPIKeys(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	keys, err := s.APIKeyService.List(claims.Id)
+	if err != nil {
+		return err
+	}
+	res := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, toAPIKeyResponse(key))
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (s *ApiHandler) HandleRevokeAPIKey(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	if err := s.APIKeyService.Revoke(claims.Id, c.Param("id")); err != nil {
+		return err
+	}
+	entry := auditEntry(c, domain.AuditAPIKeyRevoked, "api_key", c.Param("id"))
+	s.AuditService.Record(entry, nil, map[string]string{"ac_number": strconv.Itoa(claims.Id)})
+	return c.NoContent(http.StatusNoContent)
+}
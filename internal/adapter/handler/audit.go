@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// auditEntry prefills an audit entry with who made the request and from where
+func auditEntry(c echo.Context, action, resource, resourceId string) domain.AuditEntry {
+	entry := domain.AuditEntry{
+		Action:     action,
+		Resource:   resource,
+		ResourceId: resourceId,
+		IP:         c.RealIP(),
+		RequestId:  c.Response().Header().Get(echo.HeaderXRequestID),
+		ActorId:    domain.AnonymousActor,
+	}
+	if claims, ok := c.Get("user").(*domain.JWTClaims); ok {
+		entry.ActorId = claims.Id
+	}
+	return entry
+}
+
+// accountSnapshot is the audited view of an account, credentials are never audited
+func accountSnapshot(acc *domain.Account) domain.AccountEventData {
+	return domain.AccountEventData{
+		AcNumber: acc.AcNumber,
+		Fname:    acc.Fname,
+		Lname:    acc.Lname,
+		Email:    acc.Email,
+	}
+}
+
+func (s *ApiHandler) HandleGetAudit(c echo.Context) error {
+	filter := domain.AuditFilter{
+		Action:     c.QueryParam("action"),
+		Resource:   c.QueryParam("resource"),
+		ResourceId: c.QueryParam("resource_id"),
+	}
+
+	verr := &domain.ValidationError{}
+	if q := c.QueryParam("actor_id"); q != "" {
+		actorId, err := strconv.Atoi(q)
+		if err != nil {
+			verr.Add("actor_id", "must be a number")
+		}
+		filter.ActorId = &actorId
+	}
+	if q := c.QueryParam("limit"); q != "" {
+		limit, err := strconv.Atoi(q)
+		if err != nil || limit < 1 {
+			verr.Add("limit", "must be a positive number")
+		}
+		filter.Limit = limit
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if q := c.QueryParam(p.name); q != "" {
+			t, err := time.Parse(time.RFC3339, q)
+			if err != nil {
+				verr.Add(p.name, "must be an RFC3339 timestamp")
+			}
+			*p.dst = t.UTC()
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+
+	entries, err := s.AuditService.Query(filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, entries)
+}
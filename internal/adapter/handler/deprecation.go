@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Deprecated announces that a route goes away at sunset, see RFC 9745 for the
+// Deprecation header and RFC 8594 for Sunset. Routes keep working after
+// since, clients are only told to move to successor.
+func Deprecated(since, sunset time.Time, successor string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+			// a route can be deprecated twice, e.g. a v1 route served through the
+			// unversioned alias, the client has to act by the earlier sunset
+			if prev, err := http.ParseTime(header.Get("Sunset")); err != nil || sunset.Before(prev) {
+				header.Set("Deprecation", fmt.Sprintf("@%d", since.Unix()))
+				header.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+				header.Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+			}
+			return next(c)
+		}
+	}
+}
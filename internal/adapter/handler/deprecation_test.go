@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestDeprecatedStacking(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	early := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		outer, inner  time.Time
+		wantSunset    time.Time
+		wantSuccessor string
+	}{
+		{"inner sunset is earlier", late, early, early, "/inner"},
+		{"outer sunset is earlier", early, late, early, "/outer"},
+		{"same sunset keeps the first", early, early, early, "/outer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+			h := Deprecated(since, tt.outer, "/outer")(Deprecated(since, tt.inner, "/inner")(func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			}))
+			if err := h(c); err != nil {
+				t.Fatal(err)
+			}
+			header := c.Response().Header()
+			if got := header.Get("Sunset"); got != tt.wantSunset.Format(http.TimeFormat) {
+				t.Errorf("Sunset = %q, want %s", got, tt.wantSunset.Format(http.TimeFormat))
+			}
+			if got, want := header.Get("Link"), "<"+tt.wantSuccessor+">; rel=\"successor-version\""; got != want {
+				t.Errorf("Link = %q, want %q", got, want)
+			}
+			if got := header.Get("Deprecation"); got != "@1767225600" {
+				t.Errorf("Deprecation = %q, want @1767225600", got)
+			}
+		})
+	}
+}
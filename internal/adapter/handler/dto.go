@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// Handlers never serialize domain models directly, these are the only shapes
+// accounts and transfers leave the API in
+
+type AccountResponse struct {
+	AcNumber  int32     `json:"ac_number"`
+	Fname     string    `json:"fname"`
+	Lname     string    `json:"lname"`
+	Email     string    `json:"email"`
+	Balance   int64     `json:"balance"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+
+	EmailVerified bool `json:"email_verified"`
+}
+
+// MaskedAccountResponse is what a customer sees of somebody else's account,
+// enough to confirm a transfer recipient without disclosing their details
+type MaskedAccountResponse struct {
+	AcNumber int32  `json:"ac_number"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+}
+
+type TransferResponse struct {
+	TransferId  string    `json:"transfer_id"`
+	FromAccount int       `json:"from_account"`
+	ToAccount   int       `json:"to_account"`
+	Amount      int64     `json:"amount"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// HistoryEntryResponse is a transfer as seen from one account, amount is
+// negative for money sent
+type HistoryEntryResponse struct {
+	TransferId   string    `json:"transfer_id"`
+	Direction    string    `json:"direction"`
+	Counterparty int       `json:"counterparty"`
+	Amount       int64     `json:"amount"`
+	Balance      int64     `json:"balance"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AccountDetailResponse is the support view of an account, status_reason
+// is why an admin last changed the status and never shown to the customer
+type AccountDetailResponse struct {
+	Account         AccountResponse    `json:"account"`
+	StatusReason    string             `json:"status_reason,omitempty"`
+	RecentTransfers []TransferResponse `json:"recent_transfers"`
+	Logins          []LoginResponse    `json:"logins"`
+	Holds           HoldsResponse      `json:"holds"`
+}
+
+// CloseAccountResponse holds the transfer that moved the remaining balance, if any
+type CloseAccountResponse struct {
+	Account  AccountResponse   `json:"account"`
+	Transfer *TransferResponse `json:"transfer"`
+}
+
+// LoginResponse is a login attempt taken from the audit log
+type LoginResponse struct {
+	Action    string    `json:"action"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HoldsResponse lists what keeps money or the customer from moving, pending
+// transfers are not debited yet and login_lockout is null unless active
+type HoldsResponse struct {
+	PendingTransfers []TransferResponse    `json:"pending_transfers"`
+	LoginLockout     *LoginLockoutResponse `json:"login_lockout"`
+}
+
+type LoginLockoutResponse struct {
+	Failures      int        `json:"failures"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+type APIKeyResponse struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// CreatedAPIKeyResponse is the only response that ever contains the key itself
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+func toAccountResponse(acc *domain.Account) AccountResponse {
+	return AccountResponse{
+		AcNumber:  acc.AcNumber,
+		Fname:     acc.Fname,
+		Lname:     acc.Lname,
+		Email:     acc.Email,
+		Balance:   acc.Balance,
+		Role:      acc.Role,
+		Status:    acc.Status,
+		CreatedAt: acc.CreatedAt,
+
+		EmailVerified: acc.EmailVerifiedAt != nil,
+	}
+}
+
+func toAccountResponses(accs []*domain.Account) []AccountResponse {
+	res := make([]AccountResponse, 0, len(accs))
+	for _, acc := range accs {
+		res = append(res, toAccountResponse(acc))
+	}
+	return res
+}
+
+func toMaskedAccountResponse(acc *domain.Account) MaskedAccountResponse {
+	return MaskedAccountResponse{
+		AcNumber: acc.AcNumber,
+		Name:     acc.MaskedName(),
+		Email:    acc.MaskedEmail(),
+	}
+}
+
+func toTransferResponse(trx *domain.TransferMessage) TransferResponse {
+	return TransferResponse{
+		TransferId:  trx.TransferId,
+		FromAccount: trx.SenderId,
+		ToAccount:   trx.ToAccount,
+		Amount:      trx.Amount,
+		Status:      trx.Status,
+		CreatedAt:   trx.CreatedAt,
+		UpdatedAt:   trx.UpdatedAt,
+	}
+}
+
+func toTransferResponses(trxs []*domain.TransferMessage) []TransferResponse {
+	res := make([]TransferResponse, 0, len(trxs))
+	for _, trx := range trxs {
+		res = append(res, toTransferResponse(trx))
+	}
+	return res
+}
+
+func toHistoryResponses(entries []*domain.HistoryEntry) []HistoryEntryResponse {
+	res := make([]HistoryEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, HistoryEntryResponse{
+			TransferId:   e.TransferId,
+			Direction:    e.Direction,
+			Counterparty: e.Counterparty,
+			Amount:       e.Amount,
+			Balance:      e.Balance,
+			CreatedAt:    e.CreatedAt,
+		})
+	}
+	return res
+}
+
+func toLoginResponses(entries []*domain.AuditEntry) []LoginResponse {
+	res := make([]LoginResponse, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, LoginResponse{Action: e.Action, IP: e.IP, CreatedAt: e.CreatedAt})
+	}
+	return res
+}
+
+func toLoginLockoutResponse(attempt *domain.LoginAttempt) *LoginLockoutResponse {
+	if attempt == nil {
+		return nil
+	}
+	return &LoginLockoutResponse{
+		Failures:      attempt.Failures,
+		NextAttemptAt: attempt.NextAttemptAt,
+		LockedUntil:   attempt.LockedUntil,
+	}
+}
+
+func toAPIKeyResponse(key *domain.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		Id:         key.Id,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// sendEmailVerification is best effort, the customer can ask for another link
+func (s *ApiHandler) sendEmailVerification(acc *domain.Account) {
+	token, err := s.AuthService.IssueEmailVerification(acc)
+	if err == nil {
+		err = s.AccountService.SendEmailVerification(acc, token)
+	}
+	if err != nil {
+		log.Printf("Error sending email verification to account %d: %v", acc.AcNumber, err)
+	}
+}
+
+func (s *ApiHandler) HandleVerifyEmail(c echo.Context) error {
+	req := new(domain.VerifyEmailReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+	claims, err := s.AuthService.ValidateEmailVerification(req.Token)
+	if err != nil {
+		return err
+	}
+
+	acc, err := s.AccountService.VerifyEmail(claims.Id, claims.Email)
+	switch {
+	case errors.Is(err, domain.ErrEmailAlreadyVerified):
+		return c.NoContent(http.StatusNoContent)
+	case err != nil:
+		return err
+	}
+
+	entry := auditEntry(c, domain.AuditEmailVerified, "account", strconv.Itoa(claims.Id))
+	entry.ActorId = claims.Id
+	s.AuditService.Record(entry, nil, map[string]any{"email": acc.Email, "email_verified_at": acc.EmailVerifiedAt})
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *ApiHandler) HandleResendEmailVerification(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	acc, err := s.AccountService.GetByAccNo(claims.Id)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+	if acc.EmailVerifiedAt != nil {
+		return echo.NewHTTPError(http.StatusConflict, domain.ErrEmailAlreadyVerified.Error())
+	}
+	s.sendEmailVerification(acc)
+	return c.JSON(http.StatusAccepted, map[string]string{"message": "A new verification link has been sent"})
+}
@@ -1,179 +1,366 @@
 package handler
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/sarthak014/Fast-Bank/internal/core/domain"
 	"github.com/sarthak014/Fast-Bank/internal/core/port"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type ApiHandler struct {
 	AccountService     port.AccountService
 	TransactionService port.TransactionService
 	AuthService        port.AuthService
+	AuditService       port.AuditService
+	TwoFactorService   port.TwoFactorService
+	LoginGuard         port.LoginGuard
+	APIKeyService      port.APIKeyService
 }
 
-func NewApiHandler(accountService port.AccountService, transactionService port.TransactionService, authService port.AuthService) *ApiHandler {
+func NewApiHandler(accountService port.AccountService, transactionService port.TransactionService, authService port.AuthService, auditService port.AuditService, twoFactorService port.TwoFactorService, loginGuard port.LoginGuard, apiKeyService port.APIKeyService) *ApiHandler {
 	return &ApiHandler{
+		APIKeyService:      apiKeyService,
+		LoginGuard:         loginGuard,
 		AuthService:        authService,
 		TransactionService: transactionService,
 		AccountService:     accountService,
+		AuditService:       auditService,
+		TwoFactorService:   twoFactorService,
 	}
 }
 
-func (s *ApiHandler) HandleGetAccount(c echo.Context) error {
-	accounts, err := s.AccountService.GetAll()
+func (s *ApiHandler) HandleGetAccountById(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	accNo, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return domain.NewValidationError("id", "must be an account number")
+	}
+
+	acc, err := s.AccountService.GetByAccNo(accNo)
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, accounts)
+	if accNo == claims.Id {
+		return c.JSON(http.StatusOK, toAccountResponse(acc))
+	}
+	if !claims.HasRole(domain.RoleSupport, domain.RoleAdmin) {
+		return c.JSON(http.StatusOK, toMaskedAccountResponse(acc))
+	}
+	s.AuditService.Record(auditEntry(c, domain.AuditAccountViewed, "account", c.Param("id")), nil, nil)
+	return c.JSON(http.StatusOK, toAccountResponse(acc))
 }
 
-func (s *ApiHandler) HandleGetAccountById(c echo.Context) error {
-	id := c.Param("id")
-	claims, _ := c.Get("user").(*domain.JWTClaims)
-	if id != strconv.Itoa(claims.Id) {
+func (s *ApiHandler) HandleGetBalance(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
 		return echo.ErrUnauthorized
 	}
+	// staff included, they see balances through the audited admin account detail
+	if c.Param("id") != strconv.Itoa(claims.Id) {
+		return &domain.ForbiddenError{Reason: "only the owner can see the balance of an account"}
+	}
 
-	acc, err := s.AccountService.GetByAccNo(claims.Id)
+	at := time.Now().UTC()
+	if q := c.QueryParam("at"); q != "" {
+		t, err := time.Parse(time.RFC3339, q)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "at must be an RFC3339 timestamp")
+		}
+		at = t.UTC()
+	}
+
+	res, err := s.AccountService.BalanceAt(claims.Id, at)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (s *ApiHandler) HandleSetRole(c echo.Context) error {
+	accNo, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return domain.NewValidationError("id", "must be an account number")
+	}
+	req := new(domain.SetRoleReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+
+	before, err := s.AccountService.GetByAccNo(accNo)
+	if err != nil {
+		return err
+	}
+	acc, err := s.AccountService.SetRole(accNo, req.Role)
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, acc)
+	s.AuditService.Record(auditEntry(c, domain.AuditRoleChanged, "account", c.Param("id")),
+		map[string]string{"role": before.Role}, map[string]string{"role": acc.Role})
+	return c.JSON(http.StatusOK, toAccountResponse(acc))
 }
 
 func (s *ApiHandler) HandleCreateAccount(c echo.Context) error {
 	accReq := new(domain.CreateAccountReq)
-	if err := c.Bind(&accReq); err != nil {
+	if err := bind(c, accReq); err != nil {
 		return err
 	}
 	acc, err := s.AccountService.Create(accReq)
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, acc)
+	entry := auditEntry(c, domain.AuditAccountCreated, "account", strconv.Itoa(int(acc.AcNumber)))
+	entry.ActorId = int(acc.AcNumber)
+	s.AuditService.Record(entry, nil, accountSnapshot(acc))
+	s.sendEmailVerification(acc)
+	return c.JSON(http.StatusOK, toAccountResponse(acc))
 }
 
+// HandleDeleteAccount closes the account, it is kept with its history. A
+// remaining balance has to go to the account given as transfer_to
 func (s *ApiHandler) HandleDeleteAccount(c echo.Context) error {
-	id := c.Param("id")
-	err := s.AccountService.Delete(id)
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	accNo, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
+		return domain.NewValidationError("id", "must be an account number")
+	}
+	if accNo != claims.Id && !claims.HasRole(domain.RoleAdmin) {
+		return echo.ErrForbidden
+	}
+	q := newListQuery(c)
+	transferTo := q.int("transfer_to")
+	if err := q.verr.Err(); err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, map[string]string{"message": "Account deleted successfully"})
+	reason := "closed by the account holder"
+	if accNo != claims.Id {
+		reason = "closed by an admin"
+	}
+	if _, err := s.closeAccount(c, domain.AuditAccountDeleted, accNo, transferTo, reason); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Account closed"})
 }
 
 func (s *ApiHandler) HandleTransfer(c echo.Context) error {
 	transferReq := new(domain.TransferReq)
-	if err := c.Bind(transferReq); err != nil {
+	if err := bind(c, transferReq); err != nil {
 		return err
 	}
-	toId, er := strconv.Atoi(c.Param("accno"))
-	if er != nil {
-		return er
-	}
 	claims, ok := c.Get("user").(*domain.JWTClaims)
 	if !ok {
 		return echo.ErrUnauthorized
 	}
-
-	senderId := claims.Id //claims.Id
-
-	// Create transfer message
-	transferMsg := domain.TransferMessage{
-		TransferId: uuid.NewString(),
-		SenderId:   senderId,
-		ToAccount:  toId,
-		Amount:     transferReq.Amount,
-		Status:     "pending",
-		CreatedAt:  time.Now().UTC(),
-		UpdatedAt:  time.Now().UTC(),
-	}
-
-	// Publish
-	err := s.TransactionService.PublishTransferMessage(transferMsg)
+	toId, err := strconv.Atoi(c.Param("accno"))
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprint("Failed to initiate transfer:", err))
+		return domain.NewValidationError("accno", "must be an account number")
 	}
-
-	err = s.TransactionService.AddTransferRecord(&transferMsg)
+	// v1 takes the recipient from the path, report it there
+	if toId == claims.Id {
+		return domain.NewValidationError("accno", "cannot transfer to your own account")
+	}
+	trx, err := s.initiateTransfer(c, claims, toId, transferReq.Amount)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Error adding transfer")
-
+		return err
 	}
 	return c.JSON(http.StatusAccepted, map[string]string{
 		"message":     "Transfer initiated",
-		"transfer_id": transferMsg.TransferId,
+		"transfer_id": trx.TransferId,
 	})
 }
 
+// initiateTransfer is shared by every API version, the audit entry is
+// recorded here because it needs the request
+func (s *ApiHandler) initiateTransfer(c echo.Context, claims *domain.JWTClaims, toId int, amount int64) (*domain.TransferMessage, error) {
+	if err := domain.ValidateTransfer(claims.Id, toId, amount); err != nil {
+		return nil, err
+	}
+	if err := s.AuthService.CheckStepUp(claims, amount); err != nil {
+		return nil, err
+	}
+	trx, err := s.TransactionService.CreateTransfer(claims.Id, toId, amount)
+	if err != nil {
+		return nil, err
+	}
+	s.AuditService.Record(auditEntry(c, domain.AuditTransferCreated, "transfer", trx.TransferId), nil, trx)
+	return trx, nil
+}
+
 func (s *ApiHandler) HandleLogin(c echo.Context) error {
-	payload := new(struct {
-		Id       int    `json:"id"`
-		Password string `json:"password"`
-	})
-	if err := c.Bind(payload); err != nil {
+	payload := new(domain.LoginReq)
+	if err := bind(c, payload); err != nil {
 		return err
 	}
-	user, err := s.AccountService.GetByAccNo(payload.Id)
-	if err != nil {
+	if err := s.LoginGuard.Check(payload.Id, c.RealIP()); err != nil {
 		return err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.EPassword), []byte(payload.Password)); err != nil {
+	entry := auditEntry(c, domain.AuditLoginFailed, "account", strconv.Itoa(payload.Id))
+	user, err := s.AccountService.Authenticate(payload.Id, payload.Password)
+	if err != nil {
+		s.AuditService.Record(entry, nil, nil)
+		s.loginFailed(c, payload.Id)
 		return echo.ErrUnauthorized
 	}
+	entry.ActorId = payload.Id
 
-	token, err := s.AuthService.Generate(int(user.AcNumber))
+	mfa, err := s.TwoFactorService.Enabled(int(user.AcNumber))
 	if err != nil {
 		return err
 	}
+	if mfa {
+		challenge, expiresIn, err := s.AuthService.IssueChallenge(user)
+		if err != nil {
+			return err
+		}
+		entry.Action = domain.AuditLoginChallenged
+		s.AuditService.Record(entry, nil, nil)
+		return c.JSON(http.StatusOK, domain.LoginChallengeRes{
+			MFARequired:    true,
+			ChallengeToken: challenge,
+			ExpiresIn:      expiresIn,
+		})
+	}
 
-	return c.JSON(200, map[string]string{
-		"token": token,
-	})
-}
+	s.loginSucceeded(payload.Id)
+	entry.Action = domain.AuditLogin
+	s.AuditService.Record(entry, nil, nil)
 
-func (s *ApiHandler) GetTransferStatus(c echo.Context) error {
-	_, ok := c.Get("user").(*domain.JWTClaims)
-	if !ok {
-		return errors.New("failed to get user claims")
+	tokens, err := s.AuthService.IssueTokens(user, domain.AuthContext{Time: time.Now(), ACR: domain.ACRPassword})
+	if err != nil {
+		return err
 	}
 
-	trxid := c.Param("id")
+	return c.JSON(200, tokens)
+}
 
-	status, err := s.TransactionService.GetTransferStatus(trxid)
+func (s *ApiHandler) HandleRefresh(c echo.Context) error {
+	payload := new(domain.RefreshReq)
+	if err := bind(c, payload); err != nil {
+		return err
+	}
+
+	tokens, err := s.AuthService.Refresh(payload.RefreshToken)
 	if err != nil {
+		return echo.ErrUnauthorized
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+func (s *ApiHandler) HandleJWKS(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(http.StatusOK, s.AuthService.JWKS())
+}
+
+func (s *ApiHandler) HandleLogout(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	payload := new(domain.LogoutReq)
+	if err := bind(c, payload); err != nil {
+		return err
+	}
+
+	if err := s.AuthService.Logout(claims, payload.RefreshToken); err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, map[string]string{"status": status})
+	s.AuditService.Record(auditEntry(c, domain.AuditLogout, "account", strconv.Itoa(claims.Id)), nil, nil)
+	return c.NoContent(http.StatusNoContent)
 }
 
-func (s *ApiHandler) JwtRoute(c echo.Context) error {
+func (s *ApiHandler) GetTransferStatus(c echo.Context) error {
 	claims, ok := c.Get("user").(*domain.JWTClaims)
 	if !ok {
-		return errors.New("failed to get user claims")
+		return echo.ErrUnauthorized
 	}
-	return c.JSON(http.StatusOK, claims)
+
+	trx, err := s.TransactionService.GetTransfer(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	// like v2 the transfer does not exist for anybody but its parties and staff
+	if trx.SenderId != claims.Id && trx.ToAccount != claims.Id && !claims.HasRole(domain.RoleSupport, domain.RoleAdmin) {
+		return echo.ErrNotFound
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": trx.Status})
 }
 
-func (s *ApiHandler) GetTrxByAcc(c echo.Context) error {
+func (s *ApiHandler) HandleTransferEvents(c echo.Context) error {
 	claims, ok := c.Get("user").(*domain.JWTClaims)
 	if !ok {
-		return errors.New("failed to get user claims")
+		return echo.ErrUnauthorized
+	}
+
+	// subscribe before reading the current state so no update is missed in between
+	events, cancel := s.TransactionService.WatchTransfer(c.Param("id"))
+	defer cancel()
+
+	trx, err := s.TransactionService.GetTransfer(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if trx.SenderId != claims.Id && trx.ToAccount != claims.Id {
+		return echo.ErrNotFound
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	current := domain.TransferStatusEvent{TransferId: trx.TransferId, Status: trx.Status, UpdatedAt: trx.UpdatedAt}
+	if err := writeStatusEvent(res, current); err != nil || current.Final() {
+		return err
+	}
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case evt := <-events:
+			if err := writeStatusEvent(res, evt); err != nil || evt.Final() {
+				return nil
+			}
+		}
 	}
-	trxs, err := s.TransactionService.GetByAccNo(claims.Id)
+}
+
+func writeStatusEvent(res *echo.Response, evt domain.TransferStatusEvent) error {
+	data, err := json.Marshal(evt)
 	if err != nil {
-		return errors.New("failed to get user transactions")
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "event: status\ndata: %s\n\n", data); err != nil {
+		return err
 	}
+	res.Flush()
+	return nil
+}
 
-	return c.JSON(http.StatusOK, trxs)
+func (s *ApiHandler) JwtRoute(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	return c.JSON(http.StatusOK, claims)
 }
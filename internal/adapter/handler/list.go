@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// listQuery reads the query parameters of list endpoints and collects every
+// problem so they are reported together
+type listQuery struct {
+	c    echo.Context
+	verr *domain.ValidationError
+}
+
+func newListQuery(c echo.Context) *listQuery {
+	return &listQuery{c: c, verr: &domain.ValidationError{}}
+}
+
+func (q *listQuery) page() domain.Page {
+	page := domain.Page{Limit: domain.DefaultPageSize, Cursor: q.c.QueryParam("cursor")}
+	if v := q.c.QueryParam("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > domain.MaxPageSize {
+			q.verr.Add("limit", fmt.Sprintf("must be between 1 and %d", domain.MaxPageSize))
+		}
+		page.Limit = limit
+	}
+	return page
+}
+
+func (q *listQuery) sort(def string, fields ...string) domain.Sort {
+	v := q.c.QueryParam("sort")
+	if v == "" {
+		v = def
+	}
+	sort, ok := domain.ParseSort(v, fields...)
+	if !ok {
+		q.verr.Add("sort", fmt.Sprintf("must be one of %v, prefixed with - for descending order", fields))
+	}
+	return sort
+}
+
+func (q *listQuery) int(name string) int {
+	v := q.c.QueryParam(name)
+	if v == "" {
+		return 0
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		q.verr.Add(name, "must be a number")
+	}
+	return i
+}
+
+func (q *listQuery) int64(name string) *int64 {
+	v := q.c.QueryParam(name)
+	if v == "" {
+		return nil
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		q.verr.Add(name, "must be a number")
+	}
+	return &i
+}
+
+func (q *listQuery) time(name string) time.Time {
+	v := q.c.QueryParam(name)
+	if v == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		q.verr.Add(name, "must be an RFC3339 timestamp")
+	}
+	return t.UTC()
+}
+
+func (q *listQuery) oneOf(name string, allowed ...string) string {
+	v := q.c.QueryParam(name)
+	if v == "" {
+		return ""
+	}
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	q.verr.Add(name, fmt.Sprintf("must be one of %v", allowed))
+	return v
+}
+
+// setNextLink points to the next page in a Link header, the body stays a
+// plain list so existing clients keep working
+func setNextLink(c echo.Context, next string) {
+	if next == "" {
+		return
+	}
+	u := *c.Request().URL
+	query := u.Query()
+	query.Set("cursor", next)
+	u.RawQuery = query.Encode()
+	// added next to the successor-version link of deprecated routes
+	c.Response().Header().Add("Link", fmt.Sprintf("<%s>; rel=\"next\"", u.RequestURI()))
+}
+
+// transferFilter reads the filters of the transfers of accNo
+func (q *listQuery) transferFilter(accNo int) domain.TransferFilter {
+	return domain.TransferFilter{
+		AccNo:        accNo,
+		Direction:    q.oneOf("direction", domain.DirectionSent, domain.DirectionReceived),
+		Counterparty: q.int("counterparty"),
+		Status:       q.oneOf("status", domain.TransferPending, domain.TransferCompleted, domain.TransferFailed),
+		MinAmount:    q.int64("min_amount"),
+		MaxAmount:    q.int64("max_amount"),
+		From:         q.time("from"),
+		To:           q.time("to"),
+		Sort:         q.sort("-created_at", domain.TransferSortFields...),
+		Page:         q.page(),
+	}
+}
+
+func (s *ApiHandler) HandleGetAccount(c echo.Context) error {
+	q := newListQuery(c)
+	filter := domain.AccountFilter{
+		Role:       q.oneOf("role", domain.RoleCustomer, domain.RoleSupport, domain.RoleAdmin),
+		Status:     q.oneOf("status", accountStatuses...),
+		MinBalance: q.int64("min_balance"),
+		MaxBalance: q.int64("max_balance"),
+		From:       q.time("from"),
+		To:         q.time("to"),
+		Sort:       q.sort("-created_at", domain.AccountSortFields...),
+		Page:       q.page(),
+	}
+	if err := q.verr.Err(); err != nil {
+		return err
+	}
+
+	accounts, next, err := s.AccountService.List(filter)
+	if err != nil {
+		return err
+	}
+	// only staff get here, every list they see is audited like a search
+	s.AuditService.Record(auditEntry(c, domain.AuditAccountSearched, "account", ""), nil, map[string]any{
+		"role":        filter.Role,
+		"status":      filter.Status,
+		"min_balance": filter.MinBalance,
+		"max_balance": filter.MaxBalance,
+		"from":        filter.From,
+		"to":          filter.To,
+		"cursor":      filter.Cursor,
+		"results":     accountNumbers(accounts),
+	})
+	setNextLink(c, next)
+	return c.JSON(http.StatusOK, toAccountResponses(accounts))
+}
+
+func (s *ApiHandler) GetTrxByAcc(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	q := newListQuery(c)
+	filter := q.transferFilter(claims.Id)
+	if err := q.verr.Err(); err != nil {
+		return err
+	}
+
+	trxs, next, err := s.TransactionService.List(filter)
+	if err != nil {
+		return err
+	}
+	setNextLink(c, next)
+	return c.JSON(http.StatusOK, toTransferResponses(trxs))
+}
+
+// HandleGetHistory lists the transfers booked on your account, sent and
+// received, with the balance after each of them
+func (s *ApiHandler) HandleGetHistory(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	if c.Param("id") != strconv.Itoa(claims.Id) {
+		return echo.ErrForbidden
+	}
+	q := newListQuery(c)
+	filter := domain.HistoryFilter{
+		AccNo:        claims.Id,
+		Direction:    q.oneOf("direction", domain.DirectionSent, domain.DirectionReceived),
+		Counterparty: q.int("counterparty"),
+		From:         q.time("from"),
+		To:           q.time("to"),
+		Sort:         q.sort("-created_at", domain.HistorySortFields...),
+		Page:         q.page(),
+	}
+	if err := q.verr.Err(); err != nil {
+		return err
+	}
+
+	entries, next, err := s.TransactionService.History(filter)
+	if err != nil {
+		return err
+	}
+	setNextLink(c, next)
+	return c.JSON(http.StatusOK, toHistoryResponses(entries))
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+func (s *ApiHandler) loginFailed(c echo.Context, accNo int) {
+	locked, err := s.LoginGuard.Failed(accNo, c.RealIP())
+	if err != nil {
+		log.Printf("Error recording failed login of account %d: %v", accNo, err)
+		return
+	}
+	if locked {
+		entry := auditEntry(c, domain.AuditAccountLocked, "account", strconv.Itoa(accNo))
+		entry.ActorId = domain.SystemActor
+		s.AuditService.Record(entry, nil, nil)
+	}
+}
+
+func (s *ApiHandler) loginSucceeded(accNo int) {
+	if err := s.LoginGuard.Succeeded(accNo); err != nil {
+		log.Printf("Error clearing failed logins of account %d: %v", accNo, err)
+	}
+}
+
+func (s *ApiHandler) HandleUnlockAccount(c echo.Context) error {
+	accNo, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return domain.NewValidationError("id", "must be an account number")
+	}
+	if err := s.LoginGuard.Unlock(accNo); err != nil {
+		return err
+	}
+	s.AuditService.Record(auditEntry(c, domain.AuditAccountUnlocked, "account", c.Param("id")), nil, nil)
+	return c.NoContent(http.StatusNoContent)
+}
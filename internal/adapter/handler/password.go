@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// HandleChangePassword sets a new password and signs the account out everywhere
+func (s *ApiHandler) HandleChangePassword(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	req := new(domain.ChangePasswordReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+
+	if err := s.AccountService.ChangePassword(claims.Id, req.OldPassword, req.NewPassword); err != nil {
+		return err
+	}
+	// whoever might know the old password is signed out, this session included
+	if err := s.AuthService.RevokeAllSessions(claims.Id); err != nil {
+		return err
+	}
+	s.AuditService.Record(auditEntry(c, domain.AuditPasswordChanged, "account", strconv.Itoa(claims.Id)), nil, nil)
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *ApiHandler) HandleForgotPassword(c echo.Context) error {
+	req := new(domain.ForgotPasswordReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+
+	if err := s.AccountService.RequestPasswordReset(req.Id); err != nil {
+		return err
+	}
+	entry := auditEntry(c, domain.AuditPasswordResetRequest, "account", strconv.Itoa(req.Id))
+	s.AuditService.Record(entry, nil, nil)
+	// same answer whether the account exists or not
+	return c.JSON(http.StatusAccepted, map[string]string{"message": "If the account exists a reset link has been sent"})
+}
+
+// HandleResetPassword sets a new password and signs the account out everywhere
+func (s *ApiHandler) HandleResetPassword(c echo.Context) error {
+	req := new(domain.ResetPasswordReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+
+	accNo, err := s.AccountService.ResetPassword(req.Token, req.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.AuthService.RevokeAllSessions(accNo); err != nil {
+		return err
+	}
+
+	entry := auditEntry(c, domain.AuditPasswordReset, "account", strconv.Itoa(accNo))
+	entry.ActorId = accNo
+	s.AuditService.Record(entry, nil, nil)
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 error response, Code is stable and meant for
+// clients to branch on, Detail is for humans and may change
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	RequestId string              `json:"request_id,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+func newProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "urn:fastbank:problem:" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// ErrorHandler replaces the echo default so every error, whether a domain
+// error from the services or an echo.HTTPError, leaves as problem+json
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	p := toProblem(c, err)
+	if p.Status >= http.StatusInternalServerError {
+		log.Printf("Error handling %s %s: %v", c.Request().Method, c.Request().URL.Path, err)
+	}
+	p.Instance = c.Request().URL.Path
+	p.RequestId = c.Response().Header().Get(echo.HeaderXRequestID)
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(p.Status)
+	} else {
+		var body []byte
+		body, err = json.Marshal(p)
+		if err == nil {
+			err = c.Blob(p.Status, problemContentType, body)
+		}
+	}
+	if err != nil {
+		log.Printf("Error writing error response: %v", err)
+	}
+}
+
+func toProblem(c echo.Context, err error) *Problem {
+	var (
+		validation *domain.ValidationError
+		policy     *domain.PasswordPolicyError
+		credential *domain.CredentialError
+		stepUp     *domain.StepUpRequiredError
+		blocked    *domain.LoginBlockedError
+		limited    *domain.RateLimitedError
+		httpErr    *echo.HTTPError
+	)
+	switch {
+	case errors.As(err, &validation):
+		p := newProblem(http.StatusBadRequest, "validation_failed", "the request has invalid fields")
+		p.Errors = validation.Fields
+		return p
+	case errors.As(err, &policy):
+		return newProblem(http.StatusBadRequest, "weak_password", policy.Error())
+	case errors.As(err, &credential):
+		return newProblem(http.StatusBadRequest, credential.Code, credential.Error())
+	case errors.As(err, &stepUp):
+		// RFC 9470, tells the client to re-authenticate through /login/step-up and retry
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, fmt.Sprintf(
+			`Bearer error="insufficient_user_authentication", error_description="%s", max_age=%d`,
+			stepUp.Error(), int(stepUp.MaxAge.Seconds())))
+		return newProblem(http.StatusUnauthorized, "step_up_required", stepUp.Error())
+	case errors.As(err, &blocked):
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+		return newProblem(http.StatusTooManyRequests, "login_blocked", blocked.Error())
+	case errors.As(err, &limited):
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		return newProblem(http.StatusTooManyRequests, "rate_limited", limited.Error())
+	case errors.Is(err, domain.ErrInsufficientBalance):
+		return newProblem(http.StatusUnprocessableEntity, "insufficient_funds", err.Error())
+	case errors.Is(err, domain.ErrAccountFrozen):
+		return newProblem(http.StatusConflict, "account_frozen", err.Error())
+	case errors.Is(err, domain.ErrAccountDebitBlocked):
+		return newProblem(http.StatusConflict, "account_debit_blocked", err.Error())
+	case errors.Is(err, domain.ErrAccountClosed):
+		return newProblem(http.StatusConflict, "account_closed", err.Error())
+	case errors.Is(err, domain.ErrBalanceNotZero):
+		return newProblem(http.StatusConflict, "balance_not_zero", err.Error())
+	case errors.Is(err, domain.ErrInvalidEmail):
+		p := newProblem(http.StatusBadRequest, "validation_failed", "the request has invalid fields")
+		p.Errors = []domain.FieldError{{Field: "email", Message: err.Error()}}
+		return p
+	case errors.Is(err, domain.ErrEmailTaken):
+		return newProblem(http.StatusConflict, "email_taken", err.Error())
+	case errors.Is(err, domain.ErrEmailNotVerified):
+		return newProblem(http.StatusForbidden, "email_not_verified", err.Error())
+	case errors.Is(err, domain.ErrTwoFactorEnabled):
+		return newProblem(http.StatusConflict, "two_factor_enabled", err.Error())
+	case errors.Is(err, domain.ErrTwoFactorNotEnabled):
+		return newProblem(http.StatusConflict, "two_factor_not_enabled", err.Error())
+	case errors.Is(err, domain.ErrNoEnrollment):
+		return newProblem(http.StatusConflict, "no_enrollment", err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		return newProblem(http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		return newProblem(http.StatusConflict, "conflict", err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		return newProblem(http.StatusForbidden, "forbidden", err.Error())
+	case errors.As(err, &httpErr):
+		detail := ""
+		if msg, ok := httpErr.Message.(string); ok && msg != http.StatusText(httpErr.Code) {
+			detail = msg
+		}
+		return newProblem(httpErr.Code, statusCode(httpErr.Code), detail)
+	default:
+		// never leak internal errors to the client
+		return newProblem(http.StatusInternalServerError, "internal_error", "")
+	}
+}
+
+// statusCode derives a stable code from the status, 404 becomes not_found
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "http_" + strconv.Itoa(status)
+	}
+	return strings.ToLower(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
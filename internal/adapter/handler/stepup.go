@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// HandleStepUp re-authenticates the current session with the password or a
+// second factor and returns an access token with a fresh auth_time
+func (s *ApiHandler) HandleStepUp(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	req := new(domain.StepUpReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+	if err := s.LoginGuard.Check(claims.Id, c.RealIP()); err != nil {
+		return err
+	}
+
+	entry := auditEntry(c, domain.AuditStepUpFailed, "account", strconv.Itoa(claims.Id))
+	auth := domain.AuthContext{Time: time.Now()}
+	var err error
+	switch {
+	case req.Code != "":
+		auth.ACR = domain.ACRMFA
+		err = s.TwoFactorService.Verify(claims.Id, req.Code)
+	default:
+		auth.ACR = domain.ACRPassword
+		_, err = s.AccountService.Authenticate(claims.Id, req.Password)
+	}
+	if err != nil {
+		s.AuditService.Record(entry, nil, nil)
+		s.loginFailed(c, claims.Id)
+		return echo.ErrUnauthorized
+	}
+	s.loginSucceeded(claims.Id)
+
+	acc, err := s.AccountService.GetByAccNo(claims.Id)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+	token, err := s.AuthService.Generate(acc, auth)
+	if err != nil {
+		return err
+	}
+	entry.Action = domain.AuditStepUp
+	s.AuditService.Record(entry, nil, map[string]string{"acr": auth.ACR})
+	return c.JSON(http.StatusOK, domain.AccessTokenRes{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.AuthService.AccessTTL().Seconds()),
+	})
+}
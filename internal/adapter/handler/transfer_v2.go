@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+func (s *ApiHandler) HandleTransferV2(c echo.Context) error {
+	transferReq := new(domain.TransferV2Req)
+	if err := bind(c, transferReq); err != nil {
+		return err
+	}
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	trx, err := s.initiateTransfer(c, claims, transferReq.ToAccount, transferReq.Amount)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderLocation, "/v2/transfer/"+trx.TransferId)
+	return c.JSON(http.StatusAccepted, toTransferResponse(trx))
+}
+
+// HandleGetTransferV2 returns the whole transfer, not just its status, and
+// only to the two parties of it
+func (s *ApiHandler) HandleGetTransferV2(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	trx, err := s.TransactionService.GetTransfer(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if trx.SenderId != claims.Id && trx.ToAccount != claims.Id {
+		return echo.ErrNotFound
+	}
+	return c.JSON(http.StatusOK, toTransferResponse(trx))
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+func (s *ApiHandler) HandleEnrollTOTP(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	enrollment, err := s.TwoFactorService.Enroll(claims.Id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, enrollment)
+}
+
+func (s *ApiHandler) HandleActivateTOTP(c echo.Context) error {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+	req := new(domain.TOTPCodeReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+
+	codes, err := s.TwoFactorService.Activate(claims.Id, req.Code)
+	if err != nil {
+		return err
+	}
+	s.AuditService.Record(auditEntry(c, domain.AuditTwoFactorEnabled, "account", strconv.Itoa(claims.Id)), nil, nil)
+	return c.JSON(http.StatusOK, map[string][]string{"recovery_codes": codes})
+}
+
+// HandleLoginChallenge completes a login of an account with 2FA enabled
+func (s *ApiHandler) HandleLoginChallenge(c echo.Context) error {
+	req := new(domain.LoginChallengeReq)
+	if err := bind(c, req); err != nil {
+		return err
+	}
+	claims, err := s.AuthService.ValidateChallenge(req.ChallengeToken)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+
+	if err := s.LoginGuard.Check(claims.Id, c.RealIP()); err != nil {
+		return err
+	}
+
+	entry := auditEntry(c, domain.AuditLoginFailed, "account", strconv.Itoa(claims.Id))
+	if err := s.TwoFactorService.Verify(claims.Id, req.Code); err != nil {
+		s.AuditService.Record(entry, nil, nil)
+		s.loginFailed(c, claims.Id)
+		return echo.ErrUnauthorized
+	}
+	s.loginSucceeded(claims.Id)
+	entry.ActorId = claims.Id
+
+	user, err := s.AccountService.GetByAccNo(claims.Id)
+	if err != nil {
+		return echo.ErrUnauthorized
+	}
+	tokens, err := s.AuthService.IssueTokens(user, domain.AuthContext{Time: time.Now(), ACR: domain.ACRMFA})
+	if err != nil {
+		return err
+	}
+	entry.Action = domain.AuditLogin
+	s.AuditService.Record(entry, nil, nil)
+	return c.JSON(http.StatusOK, tokens)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// requestValidator checks the validate tags of request structs and reports
+// every failing field at once, using the JSON names clients know
+type requestValidator struct {
+	validate *validator.Validate
+}
+
+func NewValidator() echo.Validator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return &requestValidator{validate: v}
+}
+
+func (r *requestValidator) Validate(i any) error {
+	err := r.validate.Struct(i)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	verr := &domain.ValidationError{}
+	for _, fe := range fieldErrs {
+		// drop the struct name, CreateAccountReq.email becomes email
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		verr.Add(field, fieldMessage(fe))
+	}
+	return verr
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return fmt.Sprintf("is required unless %s is given", strings.ToLower(fe.Param()))
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have at least %s items", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s characters", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fe.Param())
+	case "len":
+		return fmt.Sprintf("must be exactly %s characters", fe.Param())
+	case "email":
+		return "must be a valid email address"
+	case "numeric":
+		return "must only contain digits"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return "is invalid (" + fe.Tag() + ")"
+	}
+}
+
+// bind decodes the request into req and validates it
+func bind(c echo.Context, req any) error {
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	return c.Validate(req)
+}
@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+)
+
+// New returns the notifier selected by kind, "log" or "file", until a real
+// mail provider is plugged in behind port.Notifier
+func New(kind, path string) (port.Notifier, error) {
+	switch kind {
+	case "", "log":
+		return &LogNotifier{}, nil
+	case "file":
+		return &FileNotifier{path: path}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
+
+type LogNotifier struct{}
+
+func (n *LogNotifier) Notify(msg domain.Notification) error {
+	log.Printf("NOTIFY to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileNotifier appends one JSON line per notification, handy to pick up reset
+// links while developing
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (n *FileNotifier) Notify(msg domain.Notification) error {
+	line, err := json.Marshal(struct {
+		SentAt time.Time `json:"sent_at"`
+		domain.Notification
+	}{time.Now().UTC(), msg})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
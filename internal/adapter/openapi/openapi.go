@@ -0,0 +1,241 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/api"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// Validator checks requests, and optionally responses, against the OpenAPI
+// document. Echo already routed the request, so operations are looked up by
+// the matched route instead of routing a second time.
+type Validator struct {
+	doc               *openapi3.T
+	routes            map[string]*routers.Route
+	validateResponses bool
+}
+
+func New(validateResponses bool) (*Validator, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(api.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %v", err)
+	}
+
+	v := &Validator{doc: doc, routes: make(map[string]*routers.Route), validateResponses: validateResponses}
+	for path, item := range doc.Paths.Map() {
+		for method, op := range item.Operations() {
+			v.routes[routeKey(method, path)] = &routers.Route{
+				Spec:      doc,
+				Path:      path,
+				PathItem:  item,
+				Method:    method,
+				Operation: op,
+			}
+		}
+	}
+	return v, nil
+}
+
+// routeKey ignores parameter names, /transfer/:accno and /transfer/{id} are the same route
+func routeKey(method, path string) string {
+	return method + " " + pathParam.ReplaceAllString(echoToOpenAPI(path), "{}")
+}
+
+// route finds the operation of an echo route, the unversioned aliases of /v1
+// are not in the document and share the operations of /v1
+func (v *Validator) route(method, path string) *routers.Route {
+	if route := v.routes[routeKey(method, path)]; route != nil {
+		return route
+	}
+	return v.routes[routeKey(method, "/v1"+path)]
+}
+
+func echoToOpenAPI(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// CheckRoutes fails when a registered route is missing from the document or
+// the document describes a route the server does not have
+func (v *Validator) CheckRoutes(routes []*echo.Route) error {
+	registered := make(map[string]bool)
+	var missing []string
+	for _, r := range routes {
+		if !standardMethod(r.Method) {
+			continue
+		}
+		route := v.route(r.Method, r.Path)
+		if route == nil {
+			missing = append(missing, r.Method+" "+r.Path)
+			continue
+		}
+		registered[routeKey(route.Method, route.Path)] = true
+	}
+	var stale []string
+	for key, route := range v.routes {
+		if !registered[key] {
+			stale = append(stale, route.Method+" "+route.Path)
+		}
+	}
+	if len(missing) == 0 && len(stale) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+	return fmt.Errorf("routes and OpenAPI document differ, undocumented routes: %v, documented but not registered: %v", missing, stale)
+}
+
+func standardMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func (v *Validator) HandleSpec(c echo.Context) error {
+	return c.JSON(http.StatusOK, v.doc)
+}
+
+// Middleware rejects requests that do not match the document with a
+// validation error listing every problem. Authentication is left to the auth middleware.
+func (v *Validator) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		route := v.route(c.Request().Method, c.Path())
+		if route == nil {
+			return next(c)
+		}
+
+		params := make(map[string]string)
+		names := pathParam.FindAllString(route.Path, -1)
+		for i, value := range c.ParamValues() {
+			if i < len(names) {
+				params[strings.Trim(names[i], "{}")] = value
+			}
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request(),
+			PathParams: params,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		ctx := c.Request().Context()
+		if err := openapi3filter.ValidateRequest(ctx, input); err != nil {
+			return validationError(err)
+		}
+
+		if !v.validateResponses || streaming(route) {
+			return next(c)
+		}
+		return v.validateResponse(ctx, c, input, next)
+	}
+}
+
+// validateResponse only logs, a response that does not match the document is
+// a bug on our side and the client should still get it
+func (v *Validator) validateResponse(ctx context.Context, c echo.Context, input *openapi3filter.RequestValidationInput, next echo.HandlerFunc) error {
+	res := c.Response()
+	recorder := &bodyRecorder{ResponseWriter: res.Writer}
+	res.Writer = recorder
+	err := next(c)
+	res.Writer = recorder.ResponseWriter
+	if err != nil || !res.Committed {
+		return err
+	}
+
+	out := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 res.Status,
+		Header:                 res.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+		Options:                &openapi3filter.Options{MultiError: true, IncludeResponseStatus: true},
+	}
+	if err := openapi3filter.ValidateResponse(ctx, out); err != nil {
+		log.Printf("Response of %s %s does not match the OpenAPI document: %v", input.Route.Method, input.Route.Path, err)
+	}
+	return nil
+}
+
+func streaming(route *routers.Route) bool {
+	for _, res := range route.Operation.Responses.Map() {
+		if res.Value != nil && res.Value.Content.Get("text/event-stream") != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validationError flattens the nested errors of kin-openapi into one field
+// error per problem, without the schema dumps
+func validationError(err error) *domain.ValidationError {
+	verr := &domain.ValidationError{}
+	collect(verr, "", err)
+	return verr
+}
+
+func collect(verr *domain.ValidationError, field string, err error) {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, inner := range e {
+			collect(verr, field, inner)
+		}
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			field = e.Parameter.Name
+		case e.RequestBody != nil:
+			field = "body"
+		}
+		if e.Err == nil {
+			verr.Add(field, e.Reason)
+			return
+		}
+		collect(verr, field, e.Err)
+	case *openapi3.SchemaError:
+		if ptr := e.JSONPointer(); len(ptr) > 0 && field == "body" {
+			field = strings.Join(ptr, ".")
+		}
+		verr.Add(field, e.Reason)
+	default:
+		verr.Add(field, err.Error())
+	}
+}
+
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
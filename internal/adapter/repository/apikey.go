@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// apiKeyTouchInterval bounds how often last_used_at is written for a busy key
+const apiKeyTouchInterval = time.Minute
+
+func (s *PGStore) CreateAPIKey(key *domain.APIKey) error {
+	return s.db.Create(key).Error
+}
+
+func (s *PGStore) GetAPIKeys(accNo int) ([]*domain.APIKey, error) {
+	var keys []*domain.APIKey
+	err := s.db.Where("ac_number = ?", accNo).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (s *PGStore) GetAPIKeyByHash(hash string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := s.db.Where("key_hash = ?", hash).First(&key).Error
+	return &key, err
+}
+
+// RevokeAPIKey reports false when the account has no such active key
+func (s *PGStore) RevokeAPIKey(accNo int, id string) (bool, error) {
+	res := s.db.Model(&domain.APIKey{}).
+		Where("id = ? AND ac_number = ? AND revoked_at IS NULL", id, accNo).
+		Update("revoked_at", time.Now().UTC())
+	return res.RowsAffected == 1, res.Error
+}
+
+func (s *PGStore) TouchAPIKey(id string) error {
+	now := time.Now().UTC()
+	return s.db.Model(&domain.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-apiKeyTouchInterval)).
+		Update("last_used_at", now).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import "github.com/sarthak014/Fast-Bank/internal/core/domain"
+
+// the audit log is append only, the store exposes no update or delete and the
+// trigger below rejects them for anyone going around it
+const auditAppendOnlySQL = `
+CREATE OR REPLACE FUNCTION audit_entries_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_entries_no_change ON audit_entries;
+CREATE TRIGGER audit_entries_no_change BEFORE UPDATE OR DELETE ON audit_entries
+	FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only();
+
+DROP TRIGGER IF EXISTS audit_entries_no_truncate ON audit_entries;
+CREATE TRIGGER audit_entries_no_truncate BEFORE TRUNCATE ON audit_entries
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_entries_append_only();
+`
+
+func (s *PGStore) initAudit() error {
+	if err := s.db.AutoMigrate(&domain.AuditEntry{}); err != nil {
+		return err
+	}
+	return s.db.Exec(auditAppendOnlySQL).Error
+}
+
+func (s *PGStore) AppendAudit(entry *domain.AuditEntry) error {
+	return s.db.Create(entry).Error
+}
+
+func (s *PGStore) GetAuditEntries(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	q := s.db.Model(&domain.AuditEntry{})
+	if filter.ActorId != nil {
+		q = q.Where("actor_id = ?", *filter.ActorId)
+	}
+	if filter.Action != "" {
+		q = q.Where("action = ?", filter.Action)
+	}
+	if len(filter.Actions) > 0 {
+		q = q.Where("action IN ?", filter.Actions)
+	}
+	if filter.Resource != "" {
+		q = q.Where("resource = ?", filter.Resource)
+	}
+	if filter.ResourceId != "" {
+		q = q.Where("resource_id = ?", filter.ResourceId)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("created_at < ?", filter.To)
+	}
+
+	var entries []*domain.AuditEntry
+	err := q.Order("id DESC").Limit(filter.Limit).Find(&entries).Error
+	return entries, err
+}
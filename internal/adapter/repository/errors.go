@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+// registerErrorTranslation turns gorm errors into domain errors after every
+// statement, so no layer above the store has to know about gorm
+func registerErrorTranslation(db *gorm.DB) error {
+	translate := func(tx *gorm.DB) {
+		if tx.Error == nil {
+			return
+		}
+		resource := strings.ReplaceAll(strings.TrimSuffix(tx.Statement.Table, "s"), "_", " ")
+		if resource == "" {
+			resource = "record"
+		}
+		switch {
+		case errors.Is(tx.Error, domain.ErrNotFound), errors.Is(tx.Error, domain.ErrConflict):
+		case errors.Is(tx.Error, gorm.ErrRecordNotFound):
+			tx.Error = &domain.NotFoundError{Resource: resource, Err: tx.Error}
+		case errors.Is(tx.Error, gorm.ErrDuplicatedKey):
+			tx.Error = &domain.ConflictError{Resource: resource, Err: tx.Error}
+		}
+	}
+
+	cb := db.Callback()
+	for name, err := range map[string]error{
+		"query":  cb.Query().After("*").Register("fastbank:translate_errors", translate),
+		"create": cb.Create().After("*").Register("fastbank:translate_errors", translate),
+		"update": cb.Update().After("*").Register("fastbank:translate_errors", translate),
+		"delete": cb.Delete().After("*").Register("fastbank:translate_errors", translate),
+		"row":    cb.Row().After("*").Register("fastbank:translate_errors", translate),
+		"raw":    cb.Raw().After("*").Register("fastbank:translate_errors", translate),
+	} {
+		if err != nil {
+			return errors.Join(errors.New("registering "+name+" callback"), err)
+		}
+	}
+	return nil
+}
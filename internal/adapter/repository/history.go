@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// historyRow is a Debited or Credited event joined with the transfer that caused it
+type historyRow struct {
+	Seq        int64
+	Type       string
+	Amount     int64
+	Balance    int64
+	TransferId string
+	CreatedAt  time.Time
+	SenderId   int
+	ToAccount  int
+}
+
+var historyColumns = map[string]column[*historyRow]{
+	"created_at": timeColumn("account_events.created_at", func(r *historyRow) time.Time { return r.CreatedAt }),
+}
+
+var historySeq = intColumn("account_events.seq", func(r *historyRow) int64 { return r.Seq })
+
+// GetHistory reads the history from the event store rather than from the
+// transfers, so it only holds what was booked and every entry knows the
+// balance it left behind
+func (s *PGStore) GetHistory(filter domain.HistoryFilter) ([]*domain.HistoryEntry, string, error) {
+	q := s.db.Table("account_events").
+		Select("account_events.seq, account_events.type, account_events.amount, account_events.balance, account_events.transfer_id, account_events.created_at, t.sender_id, t.to_account").
+		Joins("JOIN transfer_messages t ON t.transfer_id = account_events.transfer_id").
+		Where("account_events.ac_number = ?", filter.AccNo)
+	switch filter.Direction {
+	case domain.DirectionSent:
+		q = q.Where("account_events.type = ?", domain.AccountDebited)
+	case domain.DirectionReceived:
+		q = q.Where("account_events.type = ?", domain.AccountCredited)
+	default:
+		q = q.Where("account_events.type IN ?", []string{domain.AccountDebited, domain.AccountCredited})
+	}
+	if filter.Counterparty != 0 {
+		q = q.Where("(t.sender_id = ? OR t.to_account = ?)", filter.Counterparty, filter.Counterparty)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("account_events.created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("account_events.created_at < ?", filter.To)
+	}
+
+	rows, next, err := paginate(q, historyColumns, historySeq, filter.Sort, filter.Page)
+	if err != nil {
+		return nil, "", err
+	}
+	entries := make([]*domain.HistoryEntry, 0, len(rows))
+	for _, r := range rows {
+		entry := &domain.HistoryEntry{
+			TransferId: r.TransferId,
+			Amount:     r.Amount,
+			Balance:    r.Balance,
+			CreatedAt:  r.CreatedAt,
+		}
+		if r.Type == domain.AccountDebited {
+			entry.Direction, entry.Counterparty, entry.Amount = domain.DirectionSent, r.ToAccount, -r.Amount
+		} else {
+			entry.Direction, entry.Counterparty = domain.DirectionReceived, r.SenderId
+		}
+		entries = append(entries, entry)
+	}
+	return entries, next, nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+func (s *PGStore) GetLoginAttempts(keys ...string) ([]*domain.LoginAttempt, error) {
+	var attempts []*domain.LoginAttempt
+	err := s.db.Where("key IN ?", keys).Find(&attempts).Error
+	return attempts, err
+}
+
+// RecordLoginFailure increments the failure counter of the key, counters of
+// keys without a failure since resetAfter start again from one
+func (s *PGStore) RecordLoginFailure(key string, resetAfter time.Time) (*domain.LoginAttempt, error) {
+	now := time.Now().UTC()
+	var attempt domain.LoginAttempt
+	err := s.db.Raw(`INSERT INTO login_attempts (key, failures, last_failure_at, next_attempt_at)
+		VALUES (?, 1, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING *`, key, now, now, resetAfter).Scan(&attempt).Error
+	return &attempt, err
+}
+
+func (s *PGStore) UpdateLoginAttempt(attempt *domain.LoginAttempt) error {
+	return s.db.Model(attempt).Select("next_attempt_at", "locked_until").Updates(attempt).Error
+}
+
+func (s *PGStore) ClearLoginAttempts(key string) error {
+	return s.db.Where("key = ?", key).Delete(&domain.LoginAttempt{}).Error
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+// the list queries filter by party and page by (sort column, id), these
+// indexes let them do both without sorting
+const listIndexesSQL = `
+CREATE INDEX IF NOT EXISTS idx_transfers_sender_created ON transfer_messages (sender_id, created_at, transfer_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_sender_amount ON transfer_messages (sender_id, amount, transfer_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_to_created ON transfer_messages (to_account, created_at, transfer_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_to_amount ON transfer_messages (to_account, amount, transfer_id);
+CREATE INDEX IF NOT EXISTS idx_account_events_history ON account_events (ac_number, created_at, seq);
+CREATE INDEX IF NOT EXISTS idx_accounts_created ON accounts (created_at, ac_number);
+CREATE INDEX IF NOT EXISTS idx_accounts_balance ON accounts (balance, ac_number);
+CREATE INDEX IF NOT EXISTS idx_accounts_email_prefix ON accounts (lower(email) text_pattern_ops);
+`
+
+// column is something a list can be sorted by, value is how a row's value is
+// kept in a cursor and parse turns it back into a query argument
+type column[T any] struct {
+	name  string
+	value func(T) string
+	parse func(string) (any, error)
+}
+
+func timeColumn[T any](name string, get func(T) time.Time) column[T] {
+	return column[T]{
+		name:  name,
+		value: func(row T) string { return get(row).UTC().Format(time.RFC3339Nano) },
+		parse: func(s string) (any, error) { return time.Parse(time.RFC3339Nano, s) },
+	}
+}
+
+func intColumn[T any](name string, get func(T) int64) column[T] {
+	return column[T]{
+		name:  name,
+		value: func(row T) string { return strconv.FormatInt(get(row), 10) },
+		parse: func(s string) (any, error) { return strconv.ParseInt(s, 10, 64) },
+	}
+}
+
+func textColumn[T any](name string, get func(T) string) column[T] {
+	return column[T]{
+		name:  name,
+		value: get,
+		parse: func(s string) (any, error) { return s, nil },
+	}
+}
+
+// cursor points behind the last row of a page, it only fits the sort it was made for
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Id    string `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, bool) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, false
+	}
+	return c, json.Unmarshal(b, &c) == nil
+}
+
+// paginate orders q by the sort column and id, continues after the cursor
+// of page and returns the cursor of the next page, empty on the last one
+func paginate[T any](q *gorm.DB, columns map[string]column[T], id column[T], sort domain.Sort, page domain.Page) ([]T, string, error) {
+	col, ok := columns[sort.Field]
+	if !ok {
+		return nil, "", domain.NewValidationError("sort", "cannot sort by "+sort.Field)
+	}
+	dir, cmp := "ASC", ">"
+	if sort.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if page.Cursor != "" {
+		invalid := domain.NewValidationError("cursor", "is invalid or was made for another sort order")
+		c, ok := decodeCursor(page.Cursor)
+		if !ok || c.Sort != sort.String() {
+			return nil, "", invalid
+		}
+		value, err := col.parse(c.Value)
+		if err != nil {
+			return nil, "", invalid
+		}
+		after, err := id.parse(c.Id)
+		if err != nil {
+			return nil, "", invalid
+		}
+		q = q.Where("("+col.name+", "+id.name+") "+cmp+" (?, ?)", value, after)
+	}
+
+	limit := page.Limit
+	if limit <= 0 || limit > domain.MaxPageSize {
+		limit = domain.DefaultPageSize
+	}
+	var rows []T
+	// one row more than asked tells whether another page follows
+	err := q.Order(col.name + " " + dir).Order(id.name + " " + dir).Limit(limit + 1).Find(&rows).Error
+	if err != nil || len(rows) <= limit {
+		return rows, "", err
+	}
+	rows = rows[:limit]
+	last := rows[limit-1]
+	return rows, encodeCursor(cursor{Sort: sort.String(), Value: col.value(last), Id: id.value(last)}), nil
+}
+
+var transferColumns = map[string]column[*domain.TransferMessage]{
+	"created_at": timeColumn("created_at", func(t *domain.TransferMessage) time.Time { return t.CreatedAt }),
+	"amount":     intColumn("amount", func(t *domain.TransferMessage) int64 { return t.Amount }),
+}
+
+var transferId = textColumn("transfer_id", func(t *domain.TransferMessage) string { return t.TransferId })
+
+func (s *PGStore) GetTransfers(filter domain.TransferFilter) ([]*domain.TransferMessage, string, error) {
+	q := s.db.Model(&domain.TransferMessage{})
+	switch filter.Direction {
+	case domain.DirectionSent:
+		q = q.Where("sender_id = ?", filter.AccNo)
+	case domain.DirectionReceived:
+		q = q.Where("to_account = ?", filter.AccNo)
+	default:
+		q = q.Where("(sender_id = ? OR to_account = ?)", filter.AccNo, filter.AccNo)
+	}
+	if filter.Counterparty != 0 {
+		q = q.Where("(sender_id = ? OR to_account = ?)", filter.Counterparty, filter.Counterparty)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.MinAmount != nil {
+		q = q.Where("amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		q = q.Where("amount <= ?", *filter.MaxAmount)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("created_at < ?", filter.To)
+	}
+	return paginate(q, transferColumns, transferId, filter.Sort, filter.Page)
+}
+
+var accountColumns = map[string]column[*domain.Account]{
+	"created_at": timeColumn("created_at", func(a *domain.Account) time.Time { return a.CreatedAt }),
+	"ac_number":  intColumn("ac_number", func(a *domain.Account) int64 { return int64(a.AcNumber) }),
+	"balance":    intColumn("balance", func(a *domain.Account) int64 { return a.Balance }),
+}
+
+var accountNumber = intColumn("ac_number", func(a *domain.Account) int64 { return int64(a.AcNumber) })
+
+func (s *PGStore) GetAccounts(filter domain.AccountFilter) ([]*domain.Account, string, error) {
+	q := s.db.Model(&domain.Account{})
+	if filter.Name != "" {
+		q = q.Where("(fname || ' ' || lname) ILIKE ?", "%"+escapeLike(filter.Name)+"%")
+	}
+	if filter.EmailPrefix != "" {
+		q = q.Where("lower(email) LIKE ?", strings.ToLower(escapeLike(filter.EmailPrefix))+"%")
+	}
+	if filter.AcNumber != 0 {
+		q = q.Where("ac_number = ?", filter.AcNumber)
+	}
+	if filter.Role != "" {
+		q = q.Where("role = ?", filter.Role)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.MinBalance != nil {
+		q = q.Where("balance >= ?", *filter.MinBalance)
+	}
+	if filter.MaxBalance != nil {
+		q = q.Where("balance <= ?", *filter.MaxBalance)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("created_at < ?", filter.To)
+	}
+	return paginate(q, accountColumns, accountNumber, filter.Sort, filter.Page)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike makes user input match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []cursor{
+		{Sort: "created_at", Value: "2026-01-02T03:04:05.123456789Z", Id: "0b1c"},
+		{Sort: "-amount", Value: "-42", Id: "tr_1"},
+		{Sort: "balance", Value: "", Id: "1007"},
+	}
+	for _, want := range tests {
+		got, ok := decodeCursor(encodeCursor(want))
+		if !ok || got != want {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v, %v", want, got, ok)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.StdEncoding.EncodeToString([]byte(`{"s":"amount","v":"1","id":"x"}`)),
+	}
+	for _, s := range tests {
+		if c, ok := decodeCursor(s); ok {
+			t.Errorf("decodeCursor(%q) = %+v, want invalid", s, c)
+		}
+	}
+}
+
+// the value kept in a cursor has to parse back to what the row had
+func TestColumnRoundTrip(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.FixedZone("CET", 3600))
+	acc := &domain.Account{AcNumber: 1007, Balance: -5, CreatedAt: created}
+	tests := []struct {
+		column string
+		want   any
+	}{
+		{"created_at", created.UTC()},
+		{"ac_number", int64(1007)},
+		{"balance", int64(-5)},
+	}
+	for _, tt := range tests {
+		col := accountColumns[tt.column]
+		got, err := col.parse(col.value(acc))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.column, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.column, got, tt.want)
+		}
+	}
+	if _, err := accountColumns["balance"].parse("1e3"); err == nil {
+		t.Error("an integer column parsed 1e3")
+	}
+	if _, err := accountColumns["created_at"].parse("yesterday"); err == nil {
+		t.Error("a time column parsed yesterday")
+	}
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"gorm.io/gorm/clause"
+)
+
+func (s *PGStore) SaveResetToken(token *domain.PasswordResetToken) error {
+	return s.db.Create(token).Error
+}
+
+// UseResetToken redeems an unused and unexpired token, the update makes sure
+// two concurrent requests cannot both redeem it
+func (s *PGStore) UseResetToken(hash string) (*domain.PasswordResetToken, error) {
+	var tokens []*domain.PasswordResetToken
+	now := time.Now().UTC()
+	err := s.db.Model(&tokens).Clauses(clause.Returning{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hash, now).
+		Update("used_at", now).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return tokens[0], nil
+}
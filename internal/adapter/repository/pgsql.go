@@ -2,11 +2,15 @@ package repository
 
 import (
 	"fmt"
+	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/sarthak014/Fast-Bank/internal/core/domain"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PGStore struct {
@@ -15,36 +19,186 @@ type PGStore struct {
 
 func NewPGStore(dsn string) (*PGStore, error) {
 	// dsn := "host=localhost user=postgres dbname=postgres password=jomum port=5432 sslmode=disable"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, err
 	}
+	if err := registerErrorTranslation(db); err != nil {
+		return nil, err
+	}
 	return &PGStore{
 		db: db,
 	}, nil
 }
 
 func (s *PGStore) Init() error {
-	err := s.db.AutoMigrate(&domain.Account{}, &domain.TransferMessage{})
+	// accounts that exist before email verification was introduced stay verified
+	grandfatherEmails := s.db.Migrator().HasTable(&domain.Account{}) && !s.db.Migrator().HasColumn(&domain.Account{}, "EmailVerifiedAt")
+	err := s.db.AutoMigrate(&domain.Account{}, &domain.TransferMessage{}, &domain.AccountEvent{}, &domain.RefreshToken{}, &domain.RevokedToken{}, &domain.TwoFactor{}, &domain.RecoveryCode{},
+		&domain.PasswordResetToken{}, &domain.SessionCutoff{}, &domain.LoginAttempt{}, &domain.APIKey{}, &domain.RateLimitCounter{})
+	if err != nil {
+		return err
+	}
+	if grandfatherEmails {
+		if err := s.db.Exec("UPDATE accounts SET email_verified_at = created_at").Error; err != nil {
+			return err
+		}
+	}
+	if err := s.db.Exec(listIndexesSQL).Error; err != nil {
+		return err
+	}
+	if err := s.initAudit(); err != nil {
+		return err
+	}
+	if err := s.backfillAccountEvents(); err != nil {
+		return err
+	}
+	return s.normalizeEmails()
+}
+
+// emails are unique regardless of case, the index used to be on the address
+// as typed and is replaced once the stored addresses are lowercased
+const emailIndexSQL = `
+UPDATE accounts SET email = lower(trim(email)) WHERE email <> lower(trim(email));
+DROP INDEX IF EXISTS idx_accounts_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_email_lower ON accounts (lower(email));
+`
+
+// normalizeEmails resolves accounts that only differ in the case of their
+// email before the address becomes unique regardless of case, the account
+// opened first keeps it and the others are moved to an undeliverable
+// placeholder until support sorts them out with the customer
+func (s *PGStore) normalizeEmails() error {
+	var dups []*domain.Account
+	err := s.db.Where("lower(trim(email)) IN (SELECT lower(trim(email)) FROM accounts GROUP BY 1 HAVING count(*) > 1)").
+		Order("lower(trim(email)), created_at, ac_number").Find(&dups).Error
+	if err != nil {
+		return err
+	}
+	kept := ""
+	for _, acc := range dups {
+		email := strings.ToLower(strings.TrimSpace(acc.Email))
+		if email != kept {
+			kept = email
+			continue
+		}
+		placeholder := fmt.Sprintf("duplicate-%d@fastbank.invalid", acc.AcNumber)
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			locked, err := lockAccount(tx, "ac_number = ?", acc.AcNumber)
+			if err != nil {
+				return err
+			}
+			return appendEvents(tx, locked, func() (*domain.AccountEvent, error) {
+				return locked.ChangeEmail(placeholder)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move account %d off duplicate email %s: %v", acc.AcNumber, email, err)
+		}
+		log.Printf("WARNING: account %d used the email %s of an older account, it was changed to %s and needs a new address", acc.AcNumber, acc.Email, placeholder)
+	}
+	return s.db.Exec(emailIndexSQL).Error
+}
+
+// backfillAccountEvents opens an event stream for accounts created before the
+// event store existed, using their current state as opening state
+func (s *PGStore) backfillAccountEvents() error {
+	var accounts []*domain.Account
+	err := s.db.Where("NOT EXISTS (SELECT 1 FROM account_events e WHERE e.ac_number = accounts.ac_number)").Find(&accounts).Error
 	if err != nil {
 		return err
 	}
+	for _, acc := range accounts {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			acc.Version = 0
+			return appendEvents(tx, acc, acc.Open)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to backfill events of account %d: %v", acc.AcNumber, err)
+		}
+	}
 	return nil
 }
-func (s *PGStore) CreateAccount(acc *domain.Account) error {
-	return s.db.Create(acc).Error
+
+// appendEvents runs the given commands against the account, stores the
+// resulting events and saves the updated projection row
+func appendEvents(tx *gorm.DB, acc *domain.Account, commands ...func() (*domain.AccountEvent, error)) error {
+	for _, command := range commands {
+		evt, err := command()
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(evt).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Save(acc).Error
 }
 
-func (s *PGStore) DeleteAccount(id int) error {
-	return s.db.Delete(&domain.Account{}, id).Error
+func lockAccount(tx *gorm.DB, query string, args ...interface{}) (*domain.Account, error) {
+	var acc domain.Account
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(&acc).Error
+	return &acc, err
+}
+
+func (s *PGStore) CreateAccount(acc *domain.Account) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// start from scratch in case an earlier attempt got rolled back
+		acc.Id, acc.Version = 0, 0
+		if err := tx.Create(acc).Error; err != nil {
+			return err
+		}
+		return appendEvents(tx, acc, acc.Open)
+	})
 }
 
-func (s *PGStore) UpdateAccount(acc *domain.Account) error {
-	return s.db.Save(acc).Error
+// ExecuteAccountCommand runs the command against the locked account and
+// persists the event it records
+func (s *PGStore) ExecuteAccountCommand(accNo int, command domain.AccountCommand) (*domain.Account, error) {
+	var acc *domain.Account
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		acc, err = lockAccount(tx, "ac_number = ?", accNo)
+		if err != nil {
+			return err
+		}
+		return appendEvents(tx, acc, func() (*domain.AccountEvent, error) {
+			return command(acc)
+		})
+	})
+	return acc, err
 }
 
-func (s *PGStore) UpdateBalance(id int, balance int64) error {
-	return s.db.Model(&domain.Account{}).Where("id = ?", id).Update("balance", balance).Error
+// CloseAccount closes the account for good, the row and its history stay.
+// With a sweep the remaining balance is first moved to sweep.ToAccount in
+// the same transaction, sweep.Amount is set to what was moved
+func (s *PGStore) CloseAccount(accNo int, reason string, sweep *domain.TransferMessage) (*domain.Account, error) {
+	var acc *domain.Account
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		accNos := []int32{int32(accNo)}
+		if sweep != nil {
+			accNos = append(accNos, int32(sweep.ToAccount))
+		}
+		locked, err := lockAccounts(tx, accNos...)
+		if err != nil {
+			return err
+		}
+		acc = locked[int32(accNo)]
+
+		if sweep != nil && acc.Balance > 0 {
+			sweep.Amount = acc.Balance
+			if err := tx.Create(sweep).Error; err != nil {
+				return err
+			}
+			if err := book(tx, acc, locked[int32(sweep.ToAccount)], sweep); err != nil {
+				return err
+			}
+		}
+		return appendEvents(tx, acc, func() (*domain.AccountEvent, error) {
+			return acc.ChangeStatus(domain.StatusClosed, reason)
+		})
+	})
+	return acc, err
 }
 
 func (s *PGStore) GetAccountById(id int) (*domain.Account, error) {
@@ -59,10 +213,10 @@ func (s *PGStore) GetAccountByAccNo(accNo int) (*domain.Account, error) {
 	return &acc, err
 }
 
-func (s *PGStore) GetAccounts() ([]*domain.Account, error) {
-	var accounts []*domain.Account
-	err := s.db.Find(&accounts).Error
-	return accounts, err
+func (s *PGStore) GetAccountByEmail(email string) (*domain.Account, error) {
+	var acc domain.Account
+	err := s.db.Where("lower(email) = lower(?)", email).First(&acc).Error
+	return &acc, err
 }
 
 func (s *PGStore) GetTransferStatus(trxid string) (string, error) {
@@ -74,6 +228,12 @@ func (s *PGStore) GetTransferStatus(trxid string) (string, error) {
 	return trx.Status, nil
 }
 
+func (s *PGStore) GetTransfer(trxid string) (*domain.TransferMessage, error) {
+	var trx domain.TransferMessage
+	err := s.db.Where("transfer_id = ?", trxid).First(&trx).Error
+	return &trx, err
+}
+
 func (s *PGStore) AddTransfer(transferMsg *domain.TransferMessage) error {
 	return s.db.Create(transferMsg).Error
 }
@@ -82,36 +242,111 @@ func (s *PGStore) UpdateTransferStatus(trxid, status string) error {
 	return s.db.Model(&domain.TransferMessage{}).Where("transfer_id = ?", trxid).Updates(map[string]interface{}{"status": status, "updated_at": time.Now().UTC()}).Error
 }
 
-func (s *PGStore) GetTransactionsByAccNo(accNo int) ([]*domain.TransferMessage, error) {
-	var trxs []*domain.TransferMessage
-	err := s.db.Where("ac_number = ?", accNo).Find(&trxs).Error
-	if err != nil {
-		return nil, err
-	}
-	return trxs, nil
-
+func (s *PGStore) Transcation(senderAccount, recipientAccount *domain.Account, msg *domain.TransferMessage) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		locked, err := lockAccounts(tx, senderAccount.AcNumber, recipientAccount.AcNumber)
+		if err != nil {
+			return err
+		}
+		return book(tx, locked[senderAccount.AcNumber], locked[recipientAccount.AcNumber], msg)
+	})
 }
 
-func (s *PGStore) Transcation(senderAccount, recipientAccount *domain.Account, msg *domain.TransferMessage) error {
-	tx := s.db.Begin()
+// lockAccounts locks the rows in a stable order so concurrent opposite
+// transfers cannot deadlock
+func lockAccounts(tx *gorm.DB, accNos ...int32) (map[int32]*domain.Account, error) {
+	sorted := append([]int32(nil), accNos...)
+	slices.Sort(sorted)
+	locked := map[int32]*domain.Account{}
+	for _, accNo := range sorted {
+		acc, err := lockAccount(tx, "ac_number = ?", accNo)
+		if err != nil {
+			return nil, err
+		}
+		locked[accNo] = acc
+	}
+	return locked, nil
+}
 
-	senderNewBalance := senderAccount.Balance - msg.Amount
-	err := tx.Model(&domain.Account{}).Where("ac_number = ?", senderAccount.AcNumber).Update("balance", senderNewBalance).Error //s.UpdateBalance(msg.SenderId, senderNewBalance)
+// book debits the sender and credits the recipient of msg, both locked
+func book(tx *gorm.DB, sender, recipient *domain.Account, msg *domain.TransferMessage) error {
+	err := appendEvents(tx, sender, func() (*domain.AccountEvent, error) {
+		return sender.Debit(msg.Amount, msg.TransferId)
+	})
 	if err != nil {
-		tx.Rollback()
-		er := s.UpdateTransferStatus(msg.TransferId, "failed")
-		if er != nil {
-			return er
-		}
-		return fmt.Errorf("failed to update sender account: %v", err)
+		return fmt.Errorf("failed to update sender account: %w", err)
 	}
 
-	recipientNewBalance := recipientAccount.Balance + msg.Amount
-	err = tx.Model(&domain.Account{}).Where("ac_number = ?", recipientAccount.AcNumber).Update("balance", recipientNewBalance).Error //.UpdateBalance(msg.ToAccount, recipientNewBalance)
+	err = appendEvents(tx, recipient, func() (*domain.AccountEvent, error) {
+		return recipient.Credit(msg.Amount, msg.TransferId)
+	})
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to update recipient account: %v", err)
+		return fmt.Errorf("failed to update recipient account: %w", err)
 	}
-	tx.Commit()
 	return nil
 }
+
+func (s *PGStore) GetAccountEvents(accNo int, until time.Time) ([]*domain.AccountEvent, error) {
+	var events []*domain.AccountEvent
+	err := s.db.Where("ac_number = ? AND created_at <= ?", accNo, until).Order("version").Find(&events).Error
+	return events, err
+}
+
+// RebuildAccountProjection replaces the accounts table with the state folded
+// from the event store and returns the number of accounts, closed ones
+// included, only accounts deleted before statuses existed are left out
+func (s *PGStore) RebuildAccountProjection() (int, error) {
+	var accounts []*domain.Account
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE accounts IN EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		rows, err := tx.Model(&domain.AccountEvent{}).Order("ac_number, version").Rows()
+		if err != nil {
+			return err
+		}
+		var acc *domain.Account
+		for rows.Next() {
+			var evt domain.AccountEvent
+			if err := tx.ScanRows(rows, &evt); err != nil {
+				rows.Close()
+				return err
+			}
+			if acc == nil || acc.AcNumber != evt.AcNumber {
+				acc = &domain.Account{}
+				accounts = append(accounts, acc)
+			}
+			if err := acc.Apply(&evt); err != nil {
+				rows.Close()
+				return err
+			}
+			if evt.Type == domain.AccountClosed {
+				acc.Id = 0
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		open := accounts[:0]
+		for _, acc := range accounts {
+			if acc.Id != 0 {
+				open = append(open, acc)
+			}
+		}
+		accounts = open
+
+		if err := tx.Exec("DELETE FROM accounts").Error; err != nil {
+			return err
+		}
+		if len(accounts) > 0 {
+			if err := tx.CreateInBatches(accounts, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("SELECT setval(pg_get_serial_sequence('accounts', 'id'), COALESCE((SELECT MAX(id) FROM accounts), 0) + 1, false)").Error
+	})
+	return len(accounts), err
+}
@@ -1,11 +1,16 @@
 package repository
 
 import (
+	"encoding/json"
 	"fmt"
 
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
 	"github.com/streadway/amqp"
 )
 
+const EventsExchange = "fastbank.events"
+
 func NewMQConnection(url string) (*amqp.Connection, error) {
 	conn, err := amqp.Dial(url)
 	if err != nil {
@@ -14,3 +19,59 @@ func NewMQConnection(url string) (*amqp.Connection, error) {
 
 	return conn, nil
 }
+
+type MQEventPublisher struct {
+	conn *amqp.Connection
+}
+
+func NewMQEventPublisher(conn *amqp.Connection) (port.EventPublisher, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	err = ch.ExchangeDeclare(
+		EventsExchange, // name
+		"topic",        // type
+		true,           // durable
+		false,          // auto-deleted
+		false,          // internal
+		false,          // no-wait
+		nil,            // arguments
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare events exchange: %v", err)
+	}
+	return &MQEventPublisher{conn: conn}, nil
+}
+
+// Publish sends the event to the events exchange using its type as routing key,
+// subscribers bind their own queues with patterns such as "transfer.*"
+func (p *MQEventPublisher) Publish(evt domain.Event) error {
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	return ch.Publish(
+		EventsExchange, // exchange
+		evt.Type,       // routing key
+		false,          // mandatory
+		false,          // immediate
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    evt.Id,
+			Type:         evt.Type,
+			Timestamp:    evt.OccurredAt,
+			Headers:      amqp.Table{"version": int32(evt.Version)},
+			Body:         body,
+		})
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// HitRateLimit counts a request of the key and returns the number of requests
+// in the window, a new window starts the count again
+func (s *PGStore) HitRateLimit(key string, windowStart time.Time) (int, error) {
+	var counter domain.RateLimitCounter
+	err := s.db.Raw(`INSERT INTO rate_limit_counters (key, window_start, count)
+		VALUES (?, ?, 1)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limit_counters.window_start = EXCLUDED.window_start THEN rate_limit_counters.count + 1 ELSE 1 END,
+			window_start = EXCLUDED.window_start
+		RETURNING *`, key, windowStart).Scan(&counter).Error
+	return counter.Count, err
+}
+
+func (s *PGStore) SweepRateLimits(before time.Time) error {
+	return s.db.Where("window_start < ?", before).Delete(&domain.RateLimitCounter{}).Error
+}
+
+// MemoryRateLimitStore keeps the counters of a single instance, limits are
+// per instance when several run behind a load balancer
+type MemoryRateLimitStore struct {
+	mu       sync.Mutex
+	counters map[string]*domain.RateLimitCounter
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{counters: make(map[string]*domain.RateLimitCounter)}
+}
+
+func (s *MemoryRateLimitStore) HitRateLimit(key string, windowStart time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, ok := s.counters[key]
+	if !ok || !counter.WindowStart.Equal(windowStart) {
+		counter = &domain.RateLimitCounter{Key: key, WindowStart: windowStart}
+		s.counters[key] = counter
+	}
+	counter.Count++
+	return counter.Count, nil
+}
+
+func (s *MemoryRateLimitStore) SweepRateLimits(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, counter := range s.counters {
+		if counter.WindowStart.Before(before) {
+			delete(s.counters, key)
+		}
+	}
+	return nil
+}
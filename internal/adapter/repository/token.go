@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTokenAlreadyRotated = errors.New("refresh token already used")
+
+func (s *PGStore) SaveRefreshToken(token *domain.RefreshToken) error {
+	return s.db.Create(token).Error
+}
+
+func (s *PGStore) GetRefreshToken(hash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	err := s.db.Where("token_hash = ?", hash).First(&token).Error
+	return &token, err
+}
+
+// RotateRefreshToken revokes the old token and stores its successor, it fails
+// when the old token was revoked concurrently
+func (s *PGStore) RotateRefreshToken(old, next *domain.RefreshToken) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domain.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", old.Id).
+			Update("revoked_at", time.Now().UTC())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrTokenAlreadyRotated
+		}
+		return tx.Create(next).Error
+	})
+}
+
+func (s *PGStore) RevokeRefreshFamily(familyId string) error {
+	return s.db.Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", time.Now().UTC()).Error
+}
+
+func (s *PGStore) RevokeToken(jti string, expiresAt time.Time) error {
+	if err := s.db.Where("expires_at < ?", time.Now().UTC()).Delete(&domain.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.RevokedToken{Jti: jti, ExpiresAt: expiresAt}).Error
+}
+
+// IsTokenRevoked checks the jti denylist and the session cutoff of the account
+func (s *PGStore) IsTokenRevoked(claims *domain.JWTClaims) (bool, error) {
+	issuedAt := time.Time{}
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time.UTC()
+	}
+	var revoked bool
+	err := s.db.Raw(`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?)
+		OR EXISTS (SELECT 1 FROM session_cutoffs WHERE ac_number = ? AND revoked_before > ?)`,
+		claims.ID, claims.Id, issuedAt).Scan(&revoked).Error
+	return revoked, err
+}
+
+// RevokeAllSessions revokes every refresh token of the account and every
+// access token issued until now
+func (s *PGStore) RevokeAllSessions(accNo int) error {
+	now := time.Now().UTC()
+	// iat only has whole seconds, a login right after the revocation must not
+	// count as issued before it
+	cutoff := now.Truncate(time.Second)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.RefreshToken{}).
+			Where("ac_number = ? AND revoked_at IS NULL", accNo).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "ac_number"}},
+			DoUpdates: clause.AssignmentColumns([]string{"revoked_before"}),
+		}).Create(&domain.SessionCutoff{AcNumber: accNo, RevokedBefore: cutoff}).Error
+	})
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s *PGStore) GetTwoFactor(accNo int) (*domain.TwoFactor, error) {
+	var tf domain.TwoFactor
+	err := s.db.Where("ac_number = ?", accNo).First(&tf).Error
+	return &tf, err
+}
+
+// SaveTwoFactor stores a pending enrollment, replacing any earlier pending one
+func (s *PGStore) SaveTwoFactor(tf *domain.TwoFactor) error {
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ac_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "enabled", "last_used_step", "enabled_at", "created_at"}),
+	}).Create(tf).Error
+}
+
+// EnableTwoFactor activates the enrollment and replaces the recovery codes
+func (s *PGStore) EnableTwoFactor(accNo int, step int64, codes []*domain.RecoveryCode) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.TwoFactor{}).Where("ac_number = ?", accNo).Updates(map[string]interface{}{
+			"enabled":        true,
+			"enabled_at":     time.Now().UTC(),
+			"last_used_step": step,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("ac_number = ?", accNo).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(codes).Error
+	})
+}
+
+// UseTOTPStep marks a time step as consumed, it reports false when that step
+// or a later one was already used so a code can never be replayed
+func (s *PGStore) UseTOTPStep(accNo int, step int64) (bool, error) {
+	res := s.db.Model(&domain.TwoFactor{}).
+		Where("ac_number = ? AND last_used_step < ?", accNo, step).
+		Update("last_used_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (s *PGStore) UseRecoveryCode(accNo int, hash string) (bool, error) {
+	res := s.db.Model(&domain.RecoveryCode{}).
+		Where("ac_number = ? AND code_hash = ? AND used_at IS NULL", accNo, hash).
+		Update("used_at", time.Now().UTC())
+	return res.RowsAffected == 1, res.Error
+}
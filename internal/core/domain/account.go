@@ -1,21 +1,272 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
 
 type Account struct {
-	Id        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Id        int       `json:"-" gorm:"primaryKey;autoIncrement"`
 	Fname     string    `json:"fname" gorm:"type:varchar(100);not null"`
 	Lname     string    `json:"lname" gorm:"type:varchar(100);not null"`
 	Email     string    `json:"email" gorm:"type:varchar(100);not null"`
-	EPassword string    `json:"epassword" gorm:"type:varchar(255);not null"`
+	EPassword string    `json:"-" gorm:"type:varchar(255);not null"`
 	AcNumber  int32     `json:"ac_number" gorm:"unique;not null"`
 	Balance   int64     `json:"balance" gorm:"not null;default:1000"`
+	Role      string    `json:"role" gorm:"type:varchar(20);not null;default:customer"`
+	Status    string    `json:"status" gorm:"type:varchar(20);not null;default:active;index"`
+	Version   int       `json:"-" gorm:"not null;default:0"`
 	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;default:current_timestamp"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" gorm:"type:timestamp"`
+	StatusReason    string     `json:"status_reason,omitempty" gorm:"type:varchar(500)"`
 }
 
 type CreateAccountReq struct {
-	Fname    string `json:"fname"`
-	Lname    string `json:"lname"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Fname    string `json:"fname" validate:"required,max=100"`
+	Lname    string `json:"lname" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"required"`
+}
+
+type SetRoleReq struct {
+	Role string `json:"role" validate:"required,oneof=customer support admin"`
+}
+
+type BalanceRes struct {
+	AcNumber int32     `json:"ac_number"`
+	Balance  int64     `json:"balance"`
+	At       time.Time `json:"at"`
+}
+
+type VerifyEmailReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
+var (
+	ErrInsufficientBalance  = errors.New("insufficient balance in sender account")
+	ErrInvalidEmail         = errors.New("invalid email address")
+	ErrEmailTaken           = errors.New("an account with this email already exists")
+	ErrEmailNotVerified     = errors.New("confirm your email address before sending transfers")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+	ErrInvalidVerification  = &CredentialError{Code: "invalid_verification_token", Reason: "invalid or expired verification token"}
+)
+
+// CanSendTransfers keeps customers who never confirmed their email from
+// moving money, staff accounts are created by admins and exempt
+func (a *Account) CanSendTransfers() error {
+	if err := a.CanDebit(); err != nil {
+		return err
+	}
+	if a.EmailVerifiedAt == nil && a.Role == RoleCustomer {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// MaskedName is the first name and the initial of the last name, enough to
+// confirm a transfer recipient
+func (a *Account) MaskedName() string {
+	if a.Lname == "" {
+		return a.Fname
+	}
+	r, _ := utf8.DecodeRuneInString(a.Lname)
+	return strings.TrimSpace(a.Fname + " " + string(r) + ".")
+}
+
+// MaskedEmail keeps the first character of the local part and the domain, "jane@example.com" becomes "j***@example.com"
+func (a *Account) MaskedEmail() string {
+	local, host, ok := strings.Cut(a.Email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	r, _ := utf8.DecodeRuneInString(local)
+	return string(r) + "***@" + host
+}
+
+// AccountCommand validates a change against the current account state and
+// records the resulting event
+type AccountCommand func(*Account) (*AccountEvent, error)
+
+// Apply folds a stored event into the account, it never validates since the
+// event already happened, validation belongs to the commands below
+func (a *Account) Apply(e *AccountEvent) error {
+	switch e.Type {
+	case AccountOpened:
+		var data AccountOpenedData
+		if err := json.Unmarshal([]byte(e.Data), &data); err != nil {
+			return fmt.Errorf("invalid %s event %d: %v", e.Type, e.Seq, err)
+		}
+		a.Id = data.Id
+		a.Fname = data.Fname
+		a.Lname = data.Lname
+		// older accounts were stored with the address as typed
+		a.Email = strings.ToLower(strings.TrimSpace(data.Email))
+		a.EPassword = data.EPassword
+		a.Role = data.Role
+		if a.Role == "" {
+			a.Role = RoleCustomer
+		}
+		a.AcNumber = e.AcNumber
+		a.Status = StatusActive
+		a.Balance = e.Amount
+		a.CreatedAt = e.CreatedAt
+		// accounts opened before email verification existed count as verified
+		if !data.EmailVerificationRequired {
+			verifiedAt := e.CreatedAt
+			a.EmailVerifiedAt = &verifiedAt
+		}
+	case AccountDebited:
+		a.Balance -= e.Amount
+	case AccountCredited:
+		a.Balance += e.Amount
+	case AccountRoleChanged:
+		var data RoleChangedData
+		if err := json.Unmarshal([]byte(e.Data), &data); err != nil {
+			return fmt.Errorf("invalid %s event %d: %v", e.Type, e.Seq, err)
+		}
+		a.Role = data.Role
+	case AccountPasswordChanged, AccountPasswordRehashed:
+		var data PasswordChangedData
+		if err := json.Unmarshal([]byte(e.Data), &data); err != nil {
+			return fmt.Errorf("invalid %s event %d: %v", e.Type, e.Seq, err)
+		}
+		a.EPassword = data.EPassword
+	case AccountEmailVerified:
+		verifiedAt := e.CreatedAt
+		a.EmailVerifiedAt = &verifiedAt
+	case AccountEmailChanged:
+		var data EmailChangedData
+		if err := json.Unmarshal([]byte(e.Data), &data); err != nil {
+			return fmt.Errorf("invalid %s event %d: %v", e.Type, e.Seq, err)
+		}
+		a.Email = data.Email
+		a.EmailVerifiedAt = nil
+	case AccountStatusChanged:
+		var data StatusChangedData
+		if err := json.Unmarshal([]byte(e.Data), &data); err != nil {
+			return fmt.Errorf("invalid %s event %d: %v", e.Type, e.Seq, err)
+		}
+		a.Status = data.Status
+		a.StatusReason = data.Reason
+	// accounts deleted before statuses existed, the projection dropped them
+	case AccountClosed:
+	default:
+		return fmt.Errorf("unknown account event type %q", e.Type)
+	}
+	a.Version = e.Version
+	return nil
+}
+
+// Open records the opening of a freshly inserted account
+func (a *Account) Open() (*AccountEvent, error) {
+	data, err := json.Marshal(AccountOpenedData{
+		Id:        a.Id,
+		Fname:     a.Fname,
+		Lname:     a.Lname,
+		Email:     a.Email,
+		EPassword: a.EPassword,
+		Role:      a.Role,
+
+		EmailVerificationRequired: a.EmailVerifiedAt == nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a.record(&AccountEvent{Type: AccountOpened, Amount: a.Balance, Data: string(data), CreatedAt: a.CreatedAt})
+}
+
+func (a *Account) Debit(amount int64, transferId string) (*AccountEvent, error) {
+	if amount <= 0 {
+		return nil, NewValidationError("amount", "must be greater than 0")
+	}
+	if err := a.CanDebit(); err != nil {
+		return nil, err
+	}
+	if a.Balance < amount {
+		return nil, ErrInsufficientBalance
+	}
+	return a.record(&AccountEvent{Type: AccountDebited, Amount: amount, TransferId: transferId})
+}
+
+func (a *Account) Credit(amount int64, transferId string) (*AccountEvent, error) {
+	if amount <= 0 {
+		return nil, NewValidationError("amount", "must be greater than 0")
+	}
+	if err := a.CanCredit(); err != nil {
+		return nil, err
+	}
+	return a.record(&AccountEvent{Type: AccountCredited, Amount: amount, TransferId: transferId})
+}
+
+func (a *Account) ChangeRole(role string) (*AccountEvent, error) {
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+	data, err := json.Marshal(RoleChangedData{Role: role})
+	if err != nil {
+		return nil, err
+	}
+	return a.record(&AccountEvent{Type: AccountRoleChanged, Data: string(data)})
+}
+
+func (a *Account) ChangePassword(hash string) (*AccountEvent, error) {
+	data, err := json.Marshal(PasswordChangedData{EPassword: hash})
+	if err != nil {
+		return nil, err
+	}
+	return a.record(&AccountEvent{Type: AccountPasswordChanged, Data: string(data)})
+}
+
+func (a *Account) RehashPassword(hash string) (*AccountEvent, error) {
+	data, err := json.Marshal(PasswordChangedData{EPassword: hash})
+	if err != nil {
+		return nil, err
+	}
+	return a.record(&AccountEvent{Type: AccountPasswordRehashed, Data: string(data)})
+}
+
+// ChangeEmail moves the account to a new address, which has to be verified again
+func (a *Account) ChangeEmail(email string) (*AccountEvent, error) {
+	data, err := json.Marshal(EmailChangedData{Email: email})
+	if err != nil {
+		return nil, err
+	}
+	return a.record(&AccountEvent{Type: AccountEmailChanged, Data: string(data)})
+}
+
+// VerifyEmail confirms the address a verification token was issued for, a
+// token for an address the account no longer has is rejected
+func (a *Account) VerifyEmail(email string) (*AccountEvent, error) {
+	if a.EmailVerifiedAt != nil {
+		return nil, ErrEmailAlreadyVerified
+	}
+	// the token was issued for an address the account no longer has
+	if a.Email != email {
+		return nil, ErrInvalidVerification
+	}
+	evt, err := a.record(&AccountEvent{Type: AccountEmailVerified})
+	if err != nil {
+		return nil, err
+	}
+	verifiedAt := evt.CreatedAt
+	a.EmailVerifiedAt = &verifiedAt
+	return evt, nil
+}
+
+func (a *Account) record(e *AccountEvent) (*AccountEvent, error) {
+	e.AcNumber = a.AcNumber
+	e.Version = a.Version + 1
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	if err := a.Apply(e); err != nil {
+		return nil, err
+	}
+	e.Balance = a.Balance
+	return e, nil
 }
@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+const (
+	AccountOpened   = "AccountOpened"
+	AccountDebited  = "Debited"
+	AccountCredited = "Credited"
+	AccountClosed   = "Closed"
+
+	AccountRoleChanged     = "RoleChanged"
+	AccountPasswordChanged = "PasswordChanged"
+	// PasswordRehashed replaces the hash of the same password, e.g. bcrypt by argon2id
+	AccountPasswordRehashed = "PasswordRehashed"
+	AccountEmailVerified    = "EmailVerified"
+	AccountEmailChanged     = "EmailChanged"
+	AccountStatusChanged    = "StatusChanged"
+)
+
+// AccountEvent is an entry of the append only account event store, the
+// accounts table is only a projection of these events
+type AccountEvent struct {
+	Seq        int64     `json:"seq" gorm:"primaryKey;autoIncrement"`
+	AcNumber   int32     `json:"ac_number" gorm:"not null;uniqueIndex:idx_account_events_version,priority:1"`
+	Version    int       `json:"version" gorm:"not null;uniqueIndex:idx_account_events_version,priority:2"`
+	Type       string    `json:"type" gorm:"type:varchar(50);not null"`
+	Amount     int64     `json:"amount" gorm:"not null;default:0"`
+	Balance    int64     `json:"balance" gorm:"not null;default:0"`
+	TransferId string    `json:"transfer_id,omitempty" gorm:"type:varchar(100);index"`
+	Data       string    `json:"-" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"type:timestamp;not null;default:current_timestamp"`
+}
+
+type AccountOpenedData struct {
+	Id        int    `json:"id"`
+	Fname     string `json:"fname"`
+	Lname     string `json:"lname"`
+	Email     string `json:"email"`
+	EPassword string `json:"epassword"`
+	Role      string `json:"role,omitempty"`
+
+	EmailVerificationRequired bool `json:"email_verification_required,omitempty"`
+}
+
+type RoleChangedData struct {
+	Role string `json:"role"`
+}
+
+type EmailChangedData struct {
+	Email string `json:"email"`
+}
+
+type PasswordChangedData struct {
+	EPassword string `json:"epassword"`
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func openAccount(t *testing.T, balance int64) (*Account, []*AccountEvent) {
+	t.Helper()
+	acc := &Account{Id: 7, Fname: "Jane", Lname: "Doe", Email: "jane@example.com", EPassword: "hash", AcNumber: 1007, Balance: balance, Role: RoleCustomer, CreatedAt: time.Now().UTC()}
+	evt, err := acc.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acc, []*AccountEvent{evt}
+}
+
+// replaying the recorded events has to rebuild the account the commands left behind
+func TestAccountApplyReplay(t *testing.T) {
+	acc, events := openAccount(t, 1000)
+	commands := []AccountCommand{
+		func(a *Account) (*AccountEvent, error) { return a.Credit(500, "t1") },
+		func(a *Account) (*AccountEvent, error) { return a.Debit(300, "t2") },
+		func(a *Account) (*AccountEvent, error) { return a.ChangeRole(RoleSupport) },
+		func(a *Account) (*AccountEvent, error) { return a.ChangePassword("new-hash") },
+		func(a *Account) (*AccountEvent, error) { return a.VerifyEmail("jane@example.com") },
+		func(a *Account) (*AccountEvent, error) { return a.ChangeEmail("jane@example.org") },
+		func(a *Account) (*AccountEvent, error) { return a.ChangeStatus(StatusDebitBlocked, "review") },
+	}
+	for _, cmd := range commands {
+		evt, err := cmd(acc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if evt.Balance != acc.Balance || evt.Version != acc.Version {
+			t.Errorf("%s event carries balance %d version %d, account has %d and %d", evt.Type, evt.Balance, evt.Version, acc.Balance, acc.Version)
+		}
+		events = append(events, evt)
+	}
+
+	replayed := &Account{}
+	for _, evt := range events {
+		if err := replayed.Apply(evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := Account{Id: 7, Fname: "Jane", Lname: "Doe", Email: "jane@example.org", EPassword: "new-hash", AcNumber: 1007, Balance: 1200, Role: RoleSupport, Status: StatusDebitBlocked, StatusReason: "review", Version: 8}
+	got := *replayed
+	got.CreatedAt = time.Time{}
+	if got != want {
+		t.Errorf("replayed account = %+v, want %+v", got, want)
+	}
+	if *acc != *replayed {
+		t.Errorf("replayed account %+v differs from the live one %+v", replayed, acc)
+	}
+}
+
+func TestAccountApplyInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		evt  *AccountEvent
+	}{
+		{"unknown type", &AccountEvent{Type: "Renamed"}},
+		{"broken opened data", &AccountEvent{Type: AccountOpened, Data: "{"}},
+		{"broken status data", &AccountEvent{Type: AccountStatusChanged, Data: "{"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (&Account{}).Apply(tt.evt); err == nil {
+				t.Error("Apply accepted the event")
+			}
+		})
+	}
+}
+
+func TestAccountChangeStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		balance int64
+		to      string
+		wantErr error
+	}{
+		{"freeze", StatusActive, 100, StatusFrozen, nil},
+		{"block debits", StatusActive, 100, StatusDebitBlocked, nil},
+		{"reopen", StatusFrozen, 100, StatusActive, nil},
+		{"close empty", StatusFrozen, 0, StatusClosed, nil},
+		{"close with balance", StatusActive, 100, StatusClosed, ErrBalanceNotZero},
+		{"closed is final", StatusClosed, 0, StatusActive, ErrAccountClosed},
+		{"same status", StatusFrozen, 100, StatusFrozen, &ValidationError{}},
+		{"unknown status", StatusActive, 100, "suspended", &ValidationError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := &Account{Status: tt.from, Balance: tt.balance}
+			_, err := acc.ChangeStatus(tt.to, "reason")
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil || acc.Status != tt.to {
+					t.Errorf("ChangeStatus = %v, status %s, want %s", err, acc.Status, tt.to)
+				}
+			case *ValidationError:
+				if !errors.As(err, &want) {
+					t.Errorf("ChangeStatus = %v, want a validation error", err)
+				}
+			default:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ChangeStatus = %v, want %v", err, tt.wantErr)
+				}
+			}
+			if err != nil && acc.Status != tt.from {
+				t.Errorf("a refused change moved the account to %s", acc.Status)
+			}
+		})
+	}
+}
+
+func TestAccountMoneyByStatus(t *testing.T) {
+	tests := []struct {
+		status     string
+		wantDebit  error
+		wantCredit error
+	}{
+		{StatusActive, nil, nil},
+		{StatusDebitBlocked, ErrAccountDebitBlocked, nil},
+		{StatusFrozen, ErrAccountFrozen, ErrAccountFrozen},
+		{StatusClosed, ErrAccountClosed, ErrAccountClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			acc := &Account{Status: tt.status, Balance: 100}
+			if _, err := acc.Debit(10, "t"); !errors.Is(err, tt.wantDebit) {
+				t.Errorf("Debit = %v, want %v", err, tt.wantDebit)
+			}
+			if _, err := acc.Credit(10, "t"); !errors.Is(err, tt.wantCredit) {
+				t.Errorf("Credit = %v, want %v", err, tt.wantCredit)
+			}
+		})
+	}
+	if _, err := (&Account{Status: StatusActive, Balance: 5}).Debit(10, "t"); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("Debit above the balance = %v, want %v", err, ErrInsufficientBalance)
+	}
+}
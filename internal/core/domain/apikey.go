@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAPIKey  = &CredentialError{Code: "invalid_api_key", Reason: "invalid api key"}
+	ErrAPIKeyNotFound = &NotFoundError{Resource: "api key"}
+)
+
+const (
+	ScopeAccountsRead   = "accounts:read"
+	ScopeTransfersRead  = "transfers:read"
+	ScopeTransfersWrite = "transfers:write"
+)
+
+func ValidScope(scope string) bool {
+	return scope == ScopeAccountsRead || scope == ScopeTransfersRead || scope == ScopeTransfersWrite
+}
+
+// APIKey lets a machine client act on behalf of an account within its
+// scopes, only the hash of the key is stored
+type APIKey struct {
+	Id         string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	AcNumber   int        `json:"ac_number" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16);not null"`
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	Scopes     string     `json:"-" gorm:"type:varchar(255);not null"`
+	LastUsedAt *time.Time `json:"last_used_at" gorm:"type:timestamp"`
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"type:timestamp"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"type:timestamp;not null;default:current_timestamp"`
+}
+
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+type CreateAPIKeyReq struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=accounts:read transfers:read transfers:write"`
+}
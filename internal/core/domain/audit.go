@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+const (
+	AuditAccountCreated        = "account.created"
+	AuditAccountDeleted        = "account.deleted"
+	AuditAccountClosed         = "account.closed"
+	AuditStatusChanged         = "account.status_changed"
+	AuditRoleChanged           = "account.role_changed"
+	AuditEmailVerified         = "account.email_verified"
+	AuditAccountLocked         = "account.login_locked"
+	AuditAccountUnlocked       = "account.login_unlocked"
+	AuditAccountSearched       = "account.searched"
+	AuditAccountViewed         = "account.viewed"
+	AuditLogin                 = "auth.login"
+	AuditLoginFailed           = "auth.login_failed"
+	AuditLoginChallenged       = "auth.login_challenged"
+	AuditLogout                = "auth.logout"
+	AuditStepUp                = "auth.step_up"
+	AuditStepUpFailed          = "auth.step_up_failed"
+	AuditTwoFactorEnabled      = "auth.2fa_enabled"
+	AuditPasswordChanged       = "auth.password_changed"
+	AuditPasswordResetRequest  = "auth.password_reset_requested"
+	AuditPasswordReset         = "auth.password_reset"
+	AuditAPIKeyCreated         = "api_key.created"
+	AuditAPIKeyRevoked         = "api_key.revoked"
+	AuditTransferCreated       = "transfer.created"
+	AuditTransferStatusChanged = "transfer.status_changed"
+)
+
+const (
+	// SystemActor is the actor of changes made by background workers
+	SystemActor = 0
+	// AnonymousActor is the actor of requests without valid credentials, e.g.
+	// a failed login names the account it tried only as the resource
+	AnonymousActor = -1
+)
+
+// AuditEntry is a row of the append only audit log, Before and After hold
+// JSON snapshots of the changed resource
+type AuditEntry struct {
+	Id         int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ActorId    int       `json:"actor_id" gorm:"not null;index"`
+	Action     string    `json:"action" gorm:"type:varchar(50);not null;index"`
+	Resource   string    `json:"resource" gorm:"type:varchar(50);not null"`
+	ResourceId string    `json:"resource_id" gorm:"type:varchar(100);not null;index"`
+	IP         string    `json:"ip" gorm:"type:varchar(64)"`
+	RequestId  string    `json:"request_id" gorm:"type:varchar(100)"`
+	Before     string    `json:"before,omitempty" gorm:"type:text"`
+	After      string    `json:"after,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"type:timestamp;not null;default:current_timestamp;index"`
+}
+
+type AuditFilter struct {
+	ActorId    *int
+	Action     string
+	Actions    []string
+	Resource   string
+	ResourceId string
+	From       time.Time
+	To         time.Time
+	Limit      int
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sentinel errors the adapters map to transport errors, match them with
+// errors.Is, the typed errors below all match one of them
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrForbidden  = errors.New("forbidden")
+	ErrValidation = errors.New("validation failed")
+	ErrCredential = errors.New("invalid credential")
+)
+
+// NotFoundError is returned by the store for a missing row, Err keeps the
+// original driver error
+type NotFoundError struct {
+	Resource string
+	Err      error
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Resource + " not found"
+}
+
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
+func (e *NotFoundError) Unwrap() error        { return e.Err }
+
+// ConflictError is returned by the store when a unique constraint is violated,
+// or with a Reason when the resource is in the wrong state for the request
+type ConflictError struct {
+	Resource string
+	Reason   string
+	Err      error
+}
+
+func (e *ConflictError) Error() string {
+	if e.Reason != "" {
+		return e.Reason
+	}
+	return e.Resource + " already exists"
+}
+
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
+func (e *ConflictError) Unwrap() error        { return e.Err }
+
+// ForbiddenError denies an action to the caller
+type ForbiddenError struct {
+	Reason string
+}
+
+func (e *ForbiddenError) Error() string {
+	if e.Reason == "" {
+		return "you are not allowed to do this"
+	}
+	return e.Reason
+}
+
+func (e *ForbiddenError) Is(target error) bool { return target == ErrForbidden }
+
+// CredentialError rejects a password, code or token the caller presented,
+// Code tells clients which one it was
+type CredentialError struct {
+	Code   string
+	Reason string
+}
+
+func (e *CredentialError) Error() string {
+	return e.Reason
+}
+
+func (e *CredentialError) Is(target error) bool { return target == ErrCredential }
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every problem of a request instead of stopping at the first
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns nil when no problem was added, so callers can return it directly
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		if f.Field == "" {
+			msgs = append(msgs, f.Message)
+			continue
+		}
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventAccountCreated    = "account.created"
+	EventAccountDeleted    = "account.deleted" // still sent next to account.closed for existing subscribers
+	EventAccountStatus     = "account.status_changed"
+	EventAccountClosed     = "account.closed"
+	EventTransferRequested = "transfer.requested"
+	EventTransferCompleted = "transfer.completed"
+	EventTransferFailed    = "transfer.failed"
+)
+
+// eventVersions holds the current payload version of every event type,
+// bump the version whenever a payload changes in a non additive way
+var eventVersions = map[string]int{
+	EventAccountCreated:    1,
+	EventAccountDeleted:    1,
+	EventAccountStatus:     1,
+	EventAccountClosed:     1,
+	EventTransferRequested: 1,
+	EventTransferCompleted: 1,
+	EventTransferFailed:    1,
+}
+
+type Event struct {
+	Id         string    `json:"id"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+func NewEvent(eventType string, data any) Event {
+	return Event{
+		Id:         uuid.NewString(),
+		Type:       eventType,
+		Version:    eventVersions[eventType],
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+type AccountEventData struct {
+	AcNumber int32  `json:"ac_number"`
+	Fname    string `json:"fname,omitempty"`
+	Lname    string `json:"lname,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+type AccountStatusEventData struct {
+	AcNumber int32  `json:"ac_number"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason"`
+}
+
+type TransferEventData struct {
+	TransferId string `json:"transfer_id"`
+	SenderId   int    `json:"sender_id"`
+	ToAccount  int    `json:"to_account"`
+	Amount     int64  `json:"amount"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+}
@@ -1,8 +1,156 @@
 package domain
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"fmt"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleSupport  = "support"
+	RoleAdmin    = "admin"
+)
+
+func ValidRole(role string) bool {
+	return role == RoleCustomer || role == RoleSupport || role == RoleAdmin
+}
+
+const (
+	TokenAccess       = "access"
+	TokenMFAChallenge = "mfa_challenge"
+	// TokenEmailVerification is mailed out to confirm the account email
+	TokenEmailVerification = "email_verification"
+)
+
+// authentication context class references, how the session last proved who it is
+const (
+	ACRPassword = "pwd"
+	ACRMFA      = "mfa"
+)
+
+type AuthContext struct {
+	Time time.Time
+	ACR  string
+}
+
+// JWTClaims identifies the caller of a request, for API key callers they are
+// built from the key instead of a token and carry its id and scopes
 type JWTClaims struct {
-	Id int `json:"id"`
+	Id        int      `json:"id"`
+	Role      string   `json:"role"`
+	TokenType string   `json:"token_type,omitempty"`
+	KeyId     string   `json:"key_id,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	Email     string   `json:"email,omitempty"`
+	// AuthTime and ACR describe the last time the user actually authenticated,
+	// which may lie well before the token was issued by a refresh
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	ACR      string           `json:"acr,omitempty"`
 	jwt.RegisteredClaims
 }
+
+func (c *JWTClaims) AuthenticatedWithin(d time.Duration) bool {
+	return c.AuthTime != nil && time.Since(c.AuthTime.Time) <= d
+}
+
+func (c *JWTClaims) IsAPIKey() bool {
+	return c.KeyId != ""
+}
+
+// HasScope reports whether the caller may use the scope, customer sessions
+// have every scope while API keys only have the ones they were created with
+func (c *JWTClaims) HasScope(scope string) bool {
+	if !c.IsAPIKey() {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAccess reports whether the token may be used to call the API, tokens
+// without a type predate typed tokens and are access tokens
+func (c *JWTClaims) IsAccess() bool {
+	return c.TokenType == "" || c.TokenType == TokenAccess
+}
+
+// HasRole reports whether the token carries one of the roles, tokens without
+// a role predate roles and belong to customers
+func (c *JWTClaims) HasRole(roles ...string) bool {
+	role := c.Role
+	if role == "" {
+		role = RoleCustomer
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type LoginReq struct {
+	Id       int    `json:"id" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type StepUpReq struct {
+	Password string `json:"password" validate:"required_without=Code"`
+	Code     string `json:"code" validate:"required_without=Password"`
+}
+
+type AccessTokenRes struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// StepUpRequiredError asks the client to authenticate again before retrying
+type StepUpRequiredError struct {
+	MaxAge time.Duration
+}
+
+func (e *StepUpRequiredError) Error() string {
+	return fmt.Sprintf("this operation requires authenticating again within the last %s", e.MaxAge)
+}
+
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutReq optionally names the refresh token whose family should be revoked too
+type LogoutReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken is only ever stored hashed, every rotation creates a new token
+// in the same family so reuse of a rotated token can revoke the whole chain
+type RefreshToken struct {
+	Id        string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	FamilyId  string     `json:"family_id" gorm:"type:varchar(36);not null;index"`
+	AcNumber  int        `json:"ac_number" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	AuthTime  time.Time  `json:"auth_time" gorm:"type:timestamp;not null;default:current_timestamp"`
+	ACR       string     `json:"acr" gorm:"type:varchar(10);not null;default:pwd"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"type:timestamp;not null"`
+	RevokedAt *time.Time `json:"revoked_at" gorm:"type:timestamp"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp;not null;default:current_timestamp"`
+}
+
+// RevokedToken is a denylisted access token, kept until the token expires anyway
+type RevokedToken struct {
+	Jti       string    `gorm:"type:varchar(36);primaryKey"`
+	ExpiresAt time.Time `gorm:"type:timestamp;not null;index"`
+}
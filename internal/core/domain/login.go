@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// LoginAttempt tracks recent failed logins for one key, an account or a client IP
+type LoginAttempt struct {
+	Key           string     `gorm:"type:varchar(100);primaryKey"`
+	Failures      int        `gorm:"not null;default:0"`
+	LastFailureAt time.Time  `gorm:"type:timestamp;not null"`
+	NextAttemptAt time.Time  `gorm:"type:timestamp;not null"`
+	LockedUntil   *time.Time `gorm:"type:timestamp"`
+}
+
+func AccountLoginKey(accNo int) string {
+	return "acc:" + strconv.Itoa(accNo)
+}
+
+func IPLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginBlockedError is returned while a key is delayed or locked out
+type LoginBlockedError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed login attempts, locked for %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// Page selects one page of a list, Cursor is opaque to everybody but the store
+// that handed it out and is empty for the first page
+type Page struct {
+	Limit  int
+	Cursor string
+}
+
+// Sort orders a list by one of its fields, ties are broken by the id
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort reads "field" or "-field" for descending order, field has to be one of fields
+func ParseSort(s string, fields ...string) (Sort, bool) {
+	sort := Sort{Field: strings.TrimPrefix(s, "-"), Desc: strings.HasPrefix(s, "-")}
+	for _, f := range fields {
+		if sort.Field == f {
+			return sort, true
+		}
+	}
+	return Sort{}, false
+}
+
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+const (
+	DirectionSent     = "sent"
+	DirectionReceived = "received"
+)
+
+var (
+	TransferSortFields = []string{"created_at", "amount"}
+	AccountSortFields  = []string{"created_at", "ac_number", "balance"}
+	HistorySortFields  = []string{"created_at"}
+)
+
+// TransferFilter selects the transfers of AccNo, zero values do not filter
+type TransferFilter struct {
+	AccNo        int
+	Direction    string
+	Counterparty int
+	Status       string
+	MinAmount    *int64
+	MaxAmount    *int64
+	From         time.Time
+	To           time.Time
+	Sort         Sort
+	Page
+}
+
+// AccountFilter selects accounts, zero values do not filter. Name matches
+// anywhere in the full name and EmailPrefix the start of the email, both
+// ignoring case
+type AccountFilter struct {
+	Name        string
+	EmailPrefix string
+	AcNumber    int
+	Role        string
+	Status      string
+	MinBalance  *int64
+	MaxBalance  *int64
+	From        time.Time
+	To          time.Time
+	Sort        Sort
+	Page
+}
+
+// HistoryFilter selects the booked transfers of AccNo, zero values do not filter
+type HistoryFilter struct {
+	AccNo        int
+	Direction    string
+	Counterparty int
+	From         time.Time
+	To           time.Time
+	Sort         Sort
+	Page
+}
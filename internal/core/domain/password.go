@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+var (
+	ErrInvalidCredentials = &CredentialError{Code: "invalid_credentials", Reason: "invalid account number or password"}
+	ErrInvalidResetToken  = &CredentialError{Code: "invalid_reset_token", Reason: "invalid or expired reset token"}
+)
+
+type ChangePasswordReq struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type ForgotPasswordReq struct {
+	Id int `json:"id" validate:"required"`
+}
+
+type ResetPasswordReq struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+// PasswordPolicyError rejects a new password that does not meet the password policy
+type PasswordPolicyError struct {
+	Reason string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return e.Reason
+}
+
+// PasswordResetToken is stored hashed and can be redeemed once before it expires
+type PasswordResetToken struct {
+	Id        string     `gorm:"type:varchar(36);primaryKey"`
+	AcNumber  int        `gorm:"not null;index"`
+	TokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"type:timestamp;not null"`
+	UsedAt    *time.Time `gorm:"type:timestamp"`
+	CreatedAt time.Time  `gorm:"type:timestamp;not null;default:current_timestamp"`
+}
+
+// SessionCutoff revokes every token of an account issued before RevokedBefore
+type SessionCutoff struct {
+	AcNumber      int       `gorm:"primaryKey;autoIncrement:false"`
+	RevokedBefore time.Time `gorm:"type:timestamp;not null"`
+}
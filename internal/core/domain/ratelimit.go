@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimit allows Limit requests per Window to every caller of the routes it
+// is applied to, Name keeps the counters of different limits apart
+type RateLimit struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitCounter counts the requests of one key in the fixed window starting at WindowStart
+type RateLimitCounter struct {
+	Key         string    `gorm:"type:varchar(200);primaryKey"`
+	WindowStart time.Time `gorm:"type:timestamp;not null;index"`
+	Count       int       `gorm:"not null;default:0"`
+}
+
+// RateLimitedError is returned once a caller used up its limit for the current window
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry in %s", e.RetryAfter.Round(time.Second))
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// an account is active until an admin restricts it, frozen accounts can
+// neither send nor receive, debit blocked ones can still receive, closed is final
+const (
+	StatusActive       = "active"
+	StatusFrozen       = "frozen"
+	StatusDebitBlocked = "debit_blocked"
+	StatusClosed       = "closed"
+)
+
+var (
+	ErrAccountFrozen       = errors.New("account is frozen")
+	ErrAccountDebitBlocked = errors.New("account is blocked for debits")
+	ErrAccountClosed       = errors.New("account is closed")
+	ErrBalanceNotZero      = errors.New("the balance has to be zero to close the account, or moved to another account")
+)
+
+// SetStatusReq restricts or reopens an account, closing goes through CloseAccountReq
+type SetStatusReq struct {
+	Status string `json:"status" validate:"required,oneof=active frozen debit_blocked"`
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// CloseAccountReq closes an account, a remaining balance is moved to
+// TransferTo and refused when it is empty
+type CloseAccountReq struct {
+	Reason     string `json:"reason" validate:"required,max=500"`
+	TransferTo int    `json:"transfer_to" validate:"omitempty,gt=0"`
+}
+
+type StatusChangedData struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// CanDebit tells whether money may leave the account
+func (a *Account) CanDebit() error {
+	switch a.Status {
+	case StatusFrozen:
+		return ErrAccountFrozen
+	case StatusDebitBlocked:
+		return ErrAccountDebitBlocked
+	case StatusClosed:
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// CanCredit tells whether money may arrive on the account
+func (a *Account) CanCredit() error {
+	switch a.Status {
+	case StatusFrozen:
+		return ErrAccountFrozen
+	case StatusClosed:
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// ChangeStatus moves the account to status, a closed account stays closed
+// and only an empty account can be closed
+func (a *Account) ChangeStatus(status, reason string) (*AccountEvent, error) {
+	switch {
+	case a.Status == StatusClosed:
+		return nil, ErrAccountClosed
+	case status == a.Status:
+		return nil, NewValidationError("status", "account is already "+status)
+	case status == StatusClosed && a.Balance != 0:
+		return nil, ErrBalanceNotZero
+	}
+	switch status {
+	case StatusActive, StatusFrozen, StatusDebitBlocked, StatusClosed:
+	default:
+		return nil, NewValidationError("status", "unknown status "+status)
+	}
+	data, err := json.Marshal(StatusChangedData{Status: status, Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+	return a.record(&AccountEvent{Type: AccountStatusChanged, Data: string(data)})
+}
@@ -2,8 +2,33 @@ package domain
 
 import "time"
 
+const (
+	TransferPending   = "pending"
+	TransferCompleted = "completed"
+	TransferFailed    = "failed"
+)
+
 type TransferReq struct {
-	Amount int64 `json:"amount"`
+	Amount int64 `json:"amount" validate:"gt=0"`
+}
+
+// TransferV2Req carries the recipient in the body instead of the path
+type TransferV2Req struct {
+	ToAccount int   `json:"to_account" validate:"gt=0"`
+	Amount    int64 `json:"amount" validate:"gt=0"`
+}
+
+// ValidateTransfer holds the rules every transfer has to follow, whether it
+// comes from the API or is replayed from the queue
+func ValidateTransfer(from, to int, amount int64) error {
+	verr := &ValidationError{}
+	if amount <= 0 {
+		verr.Add("amount", "must be greater than 0")
+	}
+	if from == to {
+		verr.Add("to_account", "cannot transfer to your own account")
+	}
+	return verr.Err()
 }
 
 type TransferMessage struct {
@@ -15,3 +40,25 @@ type TransferMessage struct {
 	CreatedAt  time.Time `json:"created_at" gorm:"type:timestamp;not null;default:current_timestamp"`
 	UpdatedAt  time.Time `json:"updated_at" gorm:"type:timestamp;not null;default:current_timestamp;autoUpdateTime"`
 }
+
+// HistoryEntry is a transfer as it was booked on one account, Amount is
+// negative for money sent and Balance is the balance right after it
+type HistoryEntry struct {
+	TransferId   string
+	Direction    string
+	Counterparty int
+	Amount       int64
+	Balance      int64
+	CreatedAt    time.Time
+}
+
+type TransferStatusEvent struct {
+	TransferId string    `json:"transfer_id"`
+	Status     string    `json:"status"`
+	Reason     string    `json:"reason,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (e TransferStatusEvent) Final() bool {
+	return e.Status == TransferCompleted || e.Status == TransferFailed
+}
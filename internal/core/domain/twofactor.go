@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+var (
+	ErrTwoFactorEnabled    = &ConflictError{Resource: "two-factor authentication", Reason: "two-factor authentication is already enabled"}
+	ErrTwoFactorNotEnabled = &ConflictError{Resource: "two-factor authentication", Reason: "two-factor authentication is not enabled"}
+	ErrNoEnrollment        = &ConflictError{Resource: "two-factor authentication", Reason: "no pending two-factor enrollment"}
+	ErrInvalidCode         = &CredentialError{Code: "invalid_code", Reason: "invalid two-factor code"}
+)
+
+// TwoFactor holds the TOTP secret of an account, it only protects logins once
+// the customer proved their app works by verifying a first code
+type TwoFactor struct {
+	AcNumber     int        `json:"ac_number" gorm:"primaryKey;autoIncrement:false"`
+	Secret       string     `json:"-" gorm:"type:varchar(64);not null"`
+	Enabled      bool       `json:"enabled" gorm:"not null;default:false"`
+	LastUsedStep int64      `json:"-" gorm:"not null;default:0"`
+	EnabledAt    *time.Time `json:"enabled_at" gorm:"type:timestamp"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"type:timestamp;not null;default:current_timestamp"`
+}
+
+type RecoveryCode struct {
+	Id        int64      `gorm:"primaryKey;autoIncrement"`
+	AcNumber  int        `gorm:"not null;index"`
+	CodeHash  string     `gorm:"type:varchar(64);not null;uniqueIndex"`
+	UsedAt    *time.Time `gorm:"type:timestamp"`
+	CreatedAt time.Time  `gorm:"type:timestamp;not null;default:current_timestamp"`
+}
+
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type TOTPCodeReq struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type LoginChallengeReq struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type LoginChallengeRes struct {
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int    `json:"expires_in"`
+}
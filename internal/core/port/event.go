@@ -0,0 +1,18 @@
+package port
+
+import "github.com/sarthak014/Fast-Bank/internal/core/domain"
+
+type EventPublisher interface {
+	Publish(domain.Event) error
+}
+
+// PasswordHasher hashes new passwords and verifies stored hashes, Verify also
+// reports when a hash is outdated and should be replaced
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) (ok bool, rehash bool)
+}
+
+type Notifier interface {
+	Notify(domain.Notification) error
+}
@@ -1,30 +1,91 @@
 package port
 
 import (
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"github.com/sarthak014/Fast-Bank/internal/core/domain"
 )
 
 type AccountService interface {
 	Create(*domain.CreateAccountReq) (*domain.Account, error)
-	Delete(string) error
-	// Update(*domain.Account) error
-	GetAll() ([]*domain.Account, error)
+	SetStatus(int, string, string) (*domain.Account, error)
+	Close(int, int, string) (*domain.Account, *domain.TransferMessage, error)
+	Authenticate(int, string) (*domain.Account, error)
+	ChangePassword(int, string, string) error
+	RequestPasswordReset(int) error
+	ResetPassword(string, string) (int, error)
+	List(domain.AccountFilter) ([]*domain.Account, string, error)
 	GetById(string) (*domain.Account, error)
 	GetByAccNo(int) (*domain.Account, error)
+	BalanceAt(int, time.Time) (*domain.BalanceRes, error)
+	SetRole(int, string) (*domain.Account, error)
+	SendEmailVerification(*domain.Account, string) error
+	VerifyEmail(int, string) (*domain.Account, error)
 }
 
 type TransactionService interface {
+	CreateTransfer(int, int, int64) (*domain.TransferMessage, error)
 	PublishTransferMessage(domain.TransferMessage) error
+	GetTransfer(string) (*domain.TransferMessage, error)
 	GetTransferStatus(string) (string, error)
+	WatchTransfer(string) (<-chan domain.TransferStatusEvent, func())
 	ExecuteTransfer(domain.TransferMessage) error
 	AddTransferRecord(*domain.TransferMessage) error
-	GetByAccNo(int) ([]*domain.TransferMessage, error)
+	List(domain.TransferFilter) ([]*domain.TransferMessage, string, error)
+	History(domain.HistoryFilter) ([]*domain.HistoryEntry, string, error)
 	ProcessTransfers()
+	ListenTransferStatus()
 }
 
 type AuthService interface {
 	Validate(string) (*domain.JWTClaims, error)
+	Authenticate(string) (*domain.JWTClaims, error)
 	Middleware(echo.HandlerFunc) echo.HandlerFunc
-	Generate(int) (string, error)
+	RequireRole(...string) echo.MiddlewareFunc
+	RequireScope(string) echo.MiddlewareFunc
+	RequireSession(echo.HandlerFunc) echo.HandlerFunc
+	Generate(*domain.Account, domain.AuthContext) (string, error)
+	IssueTokens(*domain.Account, domain.AuthContext) (*domain.TokenPair, error)
+	CheckStepUp(*domain.JWTClaims, int64) error
+	AccessTTL() time.Duration
+	IssueChallenge(*domain.Account) (string, int, error)
+	ValidateChallenge(string) (*domain.JWTClaims, error)
+	IssueEmailVerification(*domain.Account) (string, error)
+	ValidateEmailVerification(string) (*domain.JWTClaims, error)
+	Refresh(string) (*domain.TokenPair, error)
+	Logout(*domain.JWTClaims, string) error
+	RevokeAllSessions(int) error
+	JWKS() domain.JWKS
+}
+
+type TwoFactorService interface {
+	Enroll(int) (*domain.TOTPEnrollment, error)
+	Activate(int, string) ([]string, error)
+	Enabled(int) (bool, error)
+	Verify(int, string) error
+}
+
+type APIKeyService interface {
+	Create(int, *domain.CreateAPIKeyReq) (*domain.APIKey, string, error)
+	List(int) ([]*domain.APIKey, error)
+	Revoke(int, string) error
+	Authenticate(string) (*domain.APIKey, error)
+}
+
+type LoginGuard interface {
+	Check(int, string) error
+	Failed(int, string) (bool, error)
+	Succeeded(int) error
+	Unlock(int) error
+	Lockout(int) (*domain.LoginAttempt, error)
+}
+
+type RateLimiter interface {
+	Limit(domain.RateLimit) echo.MiddlewareFunc
+}
+
+type AuditService interface {
+	Record(entry domain.AuditEntry, before, after any)
+	Query(domain.AuditFilter) ([]*domain.AuditEntry, error)
 }
@@ -1,18 +1,74 @@
 package port
 
-import "github.com/sarthak014/Fast-Bank/internal/core/domain"
+import (
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
 
 type StorageService interface {
 	CreateAccount(*domain.Account) error
-	DeleteAccount(int) error
-	UpdateAccount(*domain.Account) error
-	GetAccounts() ([]*domain.Account, error)
+	CloseAccount(int, string, *domain.TransferMessage) (*domain.Account, error)
+	ExecuteAccountCommand(int, domain.AccountCommand) (*domain.Account, error)
+	GetAccounts(domain.AccountFilter) ([]*domain.Account, string, error)
 	GetAccountById(int) (*domain.Account, error)
 	GetAccountByAccNo(int) (*domain.Account, error)
-	UpdateBalance(int, int64) error
+	GetAccountByEmail(string) (*domain.Account, error)
 	AddTransfer(*domain.TransferMessage) error
+	GetTransfer(string) (*domain.TransferMessage, error)
 	GetTransferStatus(string) (string, error)
 	UpdateTransferStatus(string, string) error
-	GetTransactionsByAccNo(int) ([]*domain.TransferMessage, error)
+	GetTransfers(domain.TransferFilter) ([]*domain.TransferMessage, string, error)
+	GetHistory(domain.HistoryFilter) ([]*domain.HistoryEntry, string, error)
 	Transcation(*domain.Account, *domain.Account, *domain.TransferMessage) error
+	GetAccountEvents(int, time.Time) ([]*domain.AccountEvent, error)
+}
+
+type AuditStore interface {
+	AppendAudit(*domain.AuditEntry) error
+	GetAuditEntries(domain.AuditFilter) ([]*domain.AuditEntry, error)
+}
+
+type TokenStore interface {
+	GetAccountByAccNo(int) (*domain.Account, error)
+	SaveRefreshToken(*domain.RefreshToken) error
+	GetRefreshToken(string) (*domain.RefreshToken, error)
+	RotateRefreshToken(old, next *domain.RefreshToken) error
+	RevokeRefreshFamily(string) error
+	RevokeToken(string, time.Time) error
+	IsTokenRevoked(*domain.JWTClaims) (bool, error)
+	RevokeAllSessions(int) error
+}
+
+type PasswordResetStore interface {
+	SaveResetToken(*domain.PasswordResetToken) error
+	UseResetToken(string) (*domain.PasswordResetToken, error)
+}
+
+type TwoFactorStore interface {
+	GetTwoFactor(int) (*domain.TwoFactor, error)
+	SaveTwoFactor(*domain.TwoFactor) error
+	EnableTwoFactor(int, int64, []*domain.RecoveryCode) error
+	UseTOTPStep(int, int64) (bool, error)
+	UseRecoveryCode(int, string) (bool, error)
+}
+
+type LoginAttemptStore interface {
+	GetLoginAttempts(...string) ([]*domain.LoginAttempt, error)
+	RecordLoginFailure(string, time.Time) (*domain.LoginAttempt, error)
+	UpdateLoginAttempt(*domain.LoginAttempt) error
+	ClearLoginAttempts(string) error
+}
+
+type RateLimitStore interface {
+	HitRateLimit(string, time.Time) (int, error)
+	SweepRateLimits(time.Time) error
+}
+
+type APIKeyStore interface {
+	CreateAPIKey(*domain.APIKey) error
+	GetAPIKeys(int) ([]*domain.APIKey, error)
+	GetAPIKeyByHash(string) (*domain.APIKey, error)
+	RevokeAPIKey(int, string) (bool, error)
+	TouchAPIKey(string) error
 }
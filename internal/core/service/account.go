@@ -1,28 +1,52 @@
 package service
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
-	"math/rand"
+	"math/big"
+	"net/mail"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/sarthak014/Fast-Bank/internal/core/domain"
 	"github.com/sarthak014/Fast-Bank/internal/core/port"
-	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	accountNumberMin      = 100000000
+	accountNumberMax      = 1000000000
+	accountNumberAttempts = 5
 )
 
 type accountService struct {
-	store port.StorageService
+	store     port.StorageService
+	resets    port.PasswordResetStore
+	events    port.EventPublisher
+	notifier  port.Notifier
+	hasher    port.PasswordHasher
+	policy    *PasswordPolicy
+	resetURL  string
+	verifyURL string
 }
 
-func NewAccountService(store port.StorageService) port.AccountService {
+func NewAccountService(store port.StorageService, resets port.PasswordResetStore, events port.EventPublisher, notifier port.Notifier, hasher port.PasswordHasher, policy *PasswordPolicy, resetURL, verifyURL string) port.AccountService {
 	return &accountService{
-		store: store,
+		store:     store,
+		resets:    resets,
+		events:    events,
+		notifier:  notifier,
+		hasher:    hasher,
+		policy:    policy,
+		resetURL:  resetURL,
+		verifyURL: verifyURL,
 	}
 }
 
-func (s *accountService) GetAll() ([]*domain.Account, error) {
-	return s.store.GetAccounts()
+func (s *accountService) List(filter domain.AccountFilter) ([]*domain.Account, string, error) {
+	return s.store.GetAccounts(filter)
 }
 
 func (s *accountService) GetById(id string) (*domain.Account, error) {
@@ -37,51 +61,174 @@ func (s *accountService) GetByAccNo(accNo int) (*domain.Account, error) {
 	return s.store.GetAccountByAccNo(accNo)
 }
 
+// BalanceAt replays the account events up to the given time
+func (s *accountService) BalanceAt(accNo int, at time.Time) (*domain.BalanceRes, error) {
+	events, err := s.store.GetAccountEvents(accNo, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, &domain.NotFoundError{Resource: fmt.Sprintf("account %d at %s", accNo, at.Format(time.RFC3339))}
+	}
+	acc := &domain.Account{}
+	for _, evt := range events {
+		if err := acc.Apply(evt); err != nil {
+			return nil, err
+		}
+	}
+	return &domain.BalanceRes{AcNumber: acc.AcNumber, Balance: acc.Balance, At: at}, nil
+}
+
+func (s *accountService) SetRole(accNo int, role string) (*domain.Account, error) {
+	return s.store.ExecuteAccountCommand(accNo, func(acc *domain.Account) (*domain.AccountEvent, error) {
+		return acc.ChangeRole(role)
+	})
+}
+
 func (s *accountService) Create(req *domain.CreateAccountReq) (*domain.Account, error) {
-	acc, err := NewAccount(req.Fname, req.Lname, req.Email, req.Password)
+	email, err := normalizeEmail(req.Email)
 	if err != nil {
 		return nil, err
 	}
-	if err := s.store.CreateAccount(acc); err != nil {
+	if _, err := s.store.GetAccountByEmail(email); err == nil {
+		return nil, domain.ErrEmailTaken
+	}
+	if err := s.policy.Check(req.Password); err != nil {
+		return nil, err
+	}
+	hash, err := s.hasher.Hash(req.Password)
+	if err != nil {
 		return nil, err
 	}
+	acc, err := NewAccount(req.Fname, req.Lname, email, hash)
+	if err != nil {
+		return nil, err
+	}
+	// account numbers are random, draw again on the rare collision
+	for attempt := 1; ; attempt++ {
+		err = s.store.CreateAccount(acc)
+		if err == nil || !errors.Is(err, domain.ErrConflict) || attempt == accountNumberAttempts {
+			break
+		}
+		if acc.AcNumber, err = newAccountNumber(); err != nil {
+			break
+		}
+	}
+	// lost the race against a signup with the same email
+	if errors.Is(err, domain.ErrConflict) {
+		return nil, domain.ErrEmailTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	publishEvent(s.events, domain.EventAccountCreated, domain.AccountEventData{
+		AcNumber: acc.AcNumber,
+		Fname:    acc.Fname,
+		Lname:    acc.Lname,
+		Email:    acc.Email,
+	})
 	return acc, nil
 }
 
-func (s *accountService) Delete(id string) error {
-	accountId, err := strconv.Atoi(id)
+func (s *accountService) SetStatus(accNo int, status, reason string) (*domain.Account, error) {
+	if status == domain.StatusClosed {
+		return nil, domain.NewValidationError("status", "close accounts through Close")
+	}
+	acc, err := s.store.ExecuteAccountCommand(accNo, func(acc *domain.Account) (*domain.AccountEvent, error) {
+		return acc.ChangeStatus(status, reason)
+	})
 	if err != nil {
-		return fmt.Errorf("invalid account ID: %v", err)
+		return nil, err
 	}
-	return s.store.DeleteAccount(accountId)
+	publishEvent(s.events, domain.EventAccountStatus, domain.AccountStatusEventData{AcNumber: acc.AcNumber, Status: status, Reason: reason})
+	return acc, nil
 }
 
-func NewAccount(fName, lName, email, password string) (*domain.Account, error) {
+// Close closes the account and keeps its history, a remaining balance is
+// moved to transferTo, the returned transfer is nil when nothing was moved
+func (s *accountService) Close(accNo, transferTo int, reason string) (*domain.Account, *domain.TransferMessage, error) {
+	var sweep *domain.TransferMessage
+	if transferTo != 0 {
+		if transferTo == accNo {
+			return nil, nil, domain.NewValidationError("transfer_to", "cannot move the balance to the account being closed")
+		}
+		now := time.Now().UTC()
+		sweep = &domain.TransferMessage{
+			TransferId: uuid.NewString(),
+			SenderId:   accNo,
+			ToAccount:  transferTo,
+			Status:     domain.TransferCompleted,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+	}
+	acc, err := s.store.CloseAccount(accNo, reason, sweep)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sweep != nil && sweep.Amount == 0 {
+		sweep = nil
+	}
+	if sweep != nil {
+		publishEvent(s.events, domain.EventTransferCompleted, transferEventData(*sweep, ""))
+	}
+	publishEvent(s.events, domain.EventAccountClosed, domain.AccountStatusEventData{AcNumber: acc.AcNumber, Status: domain.StatusClosed, Reason: reason})
+	publishEvent(s.events, domain.EventAccountDeleted, domain.AccountEventData{AcNumber: acc.AcNumber})
+	return acc, sweep, nil
+}
+
+// NewAccount builds a new customer account around an already hashed password
+func NewAccount(fName, lName, email, passwordHash string) (*domain.Account, error) {
 	if fName == "" {
 		return nil, fmt.Errorf("first name is required")
 	}
 	if lName == "" {
 		return nil, fmt.Errorf("last name is required")
 	}
-	if password == "" {
+	if passwordHash == "" {
 		return nil, fmt.Errorf("password is required")
 	}
 	if email == "" {
 		return nil, fmt.Errorf("email is required")
 	}
 
-	encpw, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	accNo, err := newAccountNumber()
 	if err != nil {
 		return nil, err
 	}
 	return &domain.Account{
 		Fname:     fName,
 		Lname:     lName,
-		EPassword: string(encpw),
+		EPassword: passwordHash,
 		Email:     email,
-		AcNumber:  rand.Int31n(100000),
+		AcNumber:  accNo,
 		Balance:   1000,
+		Role:      domain.RoleCustomer,
 		CreatedAt: time.Now().UTC(),
 	}, nil
 
 }
+
+// normalizeEmail accepts a bare address only, no display name, and lower
+// cases it so uniqueness does not depend on spelling
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", fmt.Errorf("email is required")
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || !strings.Contains(email[strings.LastIndex(email, "@"):], ".") {
+		return "", domain.ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// newAccountNumber draws a 9 digit account number from a cryptographic source
+// so numbers can be neither enumerated nor predicted
+func newAccountNumber() (int32, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(accountNumberMax-accountNumberMin))
+	if err != nil {
+		return 0, err
+	}
+	return int32(accountNumberMin + n.Int64()), nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+)
+
+// apiKeyPrefix makes FastBank keys recognisable, e.g. by secret scanners
+const apiKeyPrefix = "fbk_"
+
+type apiKeyService struct {
+	store port.APIKeyStore
+}
+
+func NewAPIKeyService(store port.APIKeyStore) port.APIKeyService {
+	return &apiKeyService{store: store}
+}
+
+// Create returns the stored key and the plain key, which is never shown again
+func (s *apiKeyService) Create(accNo int, req *domain.CreateAPIKeyReq) (*domain.APIKey, string, error) {
+	verr := &domain.ValidationError{}
+	if strings.TrimSpace(req.Name) == "" {
+		verr.Add("name", "is required")
+	}
+	if len(req.Scopes) == 0 {
+		verr.Add("scopes", "at least one scope is required")
+	}
+	for _, scope := range req.Scopes {
+		if !domain.ValidScope(scope) {
+			verr.Add("scopes", fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	plain := apiKeyPrefix + secret
+	key := &domain.APIKey{
+		Id:        uuid.NewString(),
+		AcNumber:  accNo,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    plain[:12],
+		KeyHash:   hashToken(plain),
+		Scopes:    strings.Join(req.Scopes, ","),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.store.CreateAPIKey(key); err != nil {
+		return nil, "", err
+	}
+	return key, plain, nil
+}
+
+func (s *apiKeyService) List(accNo int) ([]*domain.APIKey, error) {
+	return s.store.GetAPIKeys(accNo)
+}
+
+func (s *apiKeyService) Revoke(accNo int, id string) error {
+	revoked, err := s.store.RevokeAPIKey(accNo, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves an active key and records its use
+func (s *apiKeyService) Authenticate(plain string) (*domain.APIKey, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	key, err := s.store.GetAPIKeyByHash(hashToken(plain))
+	if err != nil || key.RevokedAt != nil {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if err := s.store.TouchAPIKey(key.Id); err != nil {
+		log.Printf("Error updating last use of api key %s: %v", key.Id, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type auditService struct {
+	store port.AuditStore
+}
+
+func NewAuditService(store port.AuditStore) port.AuditService {
+	return &auditService{store: store}
+}
+
+// Record appends an entry with JSON snapshots of the resource before and after
+// the change, a nil snapshot is left empty
+func (s *auditService) Record(entry domain.AuditEntry, before, after any) {
+	entry.Before = snapshot(before)
+	entry.After = snapshot(after)
+	entry.CreatedAt = time.Now().UTC()
+	if err := s.store.AppendAudit(&entry); err != nil {
+		log.Printf("Error writing audit entry %s %s/%s: %v", entry.Action, entry.Resource, entry.ResourceId, err)
+	}
+}
+
+func (s *auditService) Query(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	return s.store.GetAuditEntries(filter)
+}
+
+func snapshot(v any) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Error marshalling audit snapshot: %v", err)
+		return ""
+	}
+	return string(b)
+}
@@ -1,39 +1,226 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"log"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/sarthak014/Fast-Bank/internal/core/domain"
 	"github.com/sarthak014/Fast-Bank/internal/core/port"
 )
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrInvalidChallenge    = errors.New("invalid or expired login challenge")
+)
+
+const (
+	challengeTTL         = 5 * time.Minute
+	emailVerificationTTL = 48 * time.Hour
+)
+
 type authService struct {
-	secretKey []byte
+	keys       *KeySet
+	store      port.TokenStore
+	apiKeys    port.APIKeyService
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+
+	stepUpThreshold int64
+	stepUpMaxAge    time.Duration
+}
+
+func NewAuthService(keys *KeySet, store port.TokenStore, apiKeys port.APIKeyService, accessTTL, refreshTTL time.Duration, stepUpThreshold int64, stepUpMaxAge time.Duration) port.AuthService {
+	return &authService{
+		keys:            keys,
+		store:           store,
+		apiKeys:         apiKeys,
+		accessTTL:       accessTTL,
+		refreshTTL:      refreshTTL,
+		stepUpThreshold: stepUpThreshold,
+		stepUpMaxAge:    stepUpMaxAge,
+	}
+}
+
+// Generate returns an access token for an account that authenticated as described by auth
+func (s *authService) Generate(acc *domain.Account, auth domain.AuthContext) (string, error) {
+	return s.sign(acc, domain.TokenAccess, s.accessTTL, &auth)
+}
+
+func (s *authService) AccessTTL() time.Duration {
+	return s.accessTTL
+}
+
+// CheckStepUp requires transfers above the threshold to come from a session
+// that authenticated recently, older sessions get a *domain.StepUpRequiredError
+func (s *authService) CheckStepUp(claims *domain.JWTClaims, amount int64) error {
+	if amount <= s.stepUpThreshold {
+		return nil
+	}
+	if claims.IsAPIKey() || !claims.AuthenticatedWithin(s.stepUpMaxAge) {
+		return &domain.StepUpRequiredError{MaxAge: s.stepUpMaxAge}
+	}
+	return nil
 }
 
-func NewAuthService(secretKey string) port.AuthService {
-	return &authService{secretKey: []byte(secretKey)}
+// IssueChallenge returns a short lived token proving the password was
+// correct, it has to be exchanged together with a second factor
+func (s *authService) IssueChallenge(acc *domain.Account) (string, int, error) {
+	token, err := s.sign(acc, domain.TokenMFAChallenge, challengeTTL, nil)
+	return token, int(challengeTTL.Seconds()), err
 }
 
-func (s *authService) Generate(userId int) (string, error) {
+func (s *authService) ValidateChallenge(tokenString string) (*domain.JWTClaims, error) {
+	claims, err := s.Validate(tokenString)
+	if err != nil || claims.TokenType != domain.TokenMFAChallenge {
+		return nil, ErrInvalidChallenge
+	}
+	return claims, nil
+}
+
+// IssueEmailVerification returns a signed token confirming the current email of the account
+func (s *authService) IssueEmailVerification(acc *domain.Account) (string, error) {
+	return s.sign(acc, domain.TokenEmailVerification, emailVerificationTTL, nil)
+}
+
+func (s *authService) ValidateEmailVerification(tokenString string) (*domain.JWTClaims, error) {
+	claims, err := s.Validate(tokenString)
+	if err != nil || claims.TokenType != domain.TokenEmailVerification || claims.Email == "" {
+		return nil, domain.ErrInvalidVerification
+	}
+	return claims, nil
+}
+
+func (s *authService) sign(acc *domain.Account, tokenType string, ttl time.Duration, auth *domain.AuthContext) (string, error) {
+	now := time.Now()
 	claims := domain.JWTClaims{
-		Id: userId,
+		Id:        int(acc.AcNumber),
+		Role:      acc.Role,
+		TokenType: tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 2)),
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 		},
 	}
+	if tokenType == domain.TokenEmailVerification {
+		claims.Email = acc.Email
+	}
+	if auth != nil {
+		claims.AuthTime = jwt.NewNumericDate(auth.Time)
+		claims.ACR = auth.ACR
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+	return s.keys.sign(claims)
+}
+
+func (s *authService) JWKS() domain.JWKS {
+	return s.keys.JWKS()
+}
+
+// IssueTokens starts a new session with an access token and the first refresh token of a new family
+func (s *authService) IssueTokens(acc *domain.Account, auth domain.AuthContext) (*domain.TokenPair, error) {
+	return s.issue(acc, auth, uuid.NewString(), nil)
+}
+
+// Refresh rotates the refresh token, presenting an already rotated token is
+// treated as theft and revokes every token of its family
+func (s *authService) Refresh(refreshToken string) (*domain.TokenPair, error) {
+	current, err := s.store.GetRefreshToken(hashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if current.RevokedAt != nil {
+		if err := s.store.RevokeRefreshFamily(current.FamilyId); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	// reload the account so role changes and deletions apply on the next refresh
+	acc, err := s.store.GetAccountByAccNo(current.AcNumber)
+	if err != nil || acc.Status == domain.StatusClosed {
+		return nil, ErrInvalidRefreshToken
+	}
+	// a refresh keeps the original authentication, only a new login or a step-up makes it fresh
+	auth := domain.AuthContext{Time: current.AuthTime, ACR: current.ACR}
+	return s.issue(acc, auth, current.FamilyId, current)
+}
+
+func (s *authService) issue(acc *domain.Account, auth domain.AuthContext, familyId string, previous *domain.RefreshToken) (*domain.TokenPair, error) {
+	access, err := s.Generate(acc, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	next := &domain.RefreshToken{
+		Id:        uuid.NewString(),
+		FamilyId:  familyId,
+		AcNumber:  int(acc.AcNumber),
+		TokenHash: hashToken(secret),
+		AuthTime:  auth.Time,
+		ACR:       auth.ACR,
+		ExpiresAt: time.Now().UTC().Add(s.refreshTTL),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if previous == nil {
+		err = s.store.SaveRefreshToken(next)
+	} else {
+		err = s.store.RotateRefreshToken(previous, next)
+	}
+	if err != nil {
+		if previous != nil {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	return &domain.TokenPair{
+		AccessToken:  access,
+		RefreshToken: secret,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	}, nil
+}
+
+func (s *authService) RevokeAllSessions(accNo int) error {
+	return s.store.RevokeAllSessions(accNo)
+}
+
+// Logout denylists the access token and, when given, revokes the refresh token family
+func (s *authService) Logout(claims *domain.JWTClaims, refreshToken string) error {
+	if err := s.store.RevokeToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+	if refreshToken == "" {
+		return nil
+	}
+	current, err := s.store.GetRefreshToken(hashToken(refreshToken))
+	if err != nil || current.AcNumber != claims.Id {
+		return ErrInvalidRefreshToken
+	}
+	return s.store.RevokeRefreshFamily(current.FamilyId)
 }
 
 func (s *authService) Validate(tokenString string) (*domain.JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &domain.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return s.secretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &domain.JWTClaims{}, s.keys.keyFunc,
+		jwt.WithValidMethods(s.keys.methods()), jwt.WithExpirationRequired())
 
 	if err != nil {
 		return nil, err
@@ -46,15 +233,29 @@ func (s *authService) Validate(tokenString string) (*domain.JWTClaims, error) {
 	return nil, errors.New("invalid token")
 }
 
+// Authenticate resolves the credential of a request, an access token or an
+// API key, to the claims of its caller
+func (s *authService) Authenticate(credential string) (*domain.JWTClaims, error) {
+	if strings.HasPrefix(credential, apiKeyPrefix) {
+		return s.apiKeyClaims(credential)
+	}
+	return s.accessClaims(credential)
+}
+
+// Middleware authenticates the caller with either a JWT bearer token or an
+// API key, sent as X-API-Key or as bearer token
 func (s *authService) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		authHeader := c.Request().Header.Get("Authorization")
-		if authHeader == "" {
+		req := c.Request()
+		tokenString, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if key := req.Header.Get("X-API-Key"); key != "" {
+			tokenString, ok = key, true
+		}
+		if !ok || tokenString == "" {
 			return echo.ErrUnauthorized
 		}
 
-		tokenString := authHeader[7:] //bearer
-		claims, err := s.Validate(tokenString)
+		claims, err := s.Authenticate(tokenString)
 		if err != nil {
 			return echo.ErrUnauthorized
 		}
@@ -63,3 +264,88 @@ func (s *authService) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
 		return next(c)
 	}
 }
+
+func (s *authService) accessClaims(tokenString string) (*domain.JWTClaims, error) {
+	claims, err := s.Validate(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.IsAccess() {
+		return nil, errors.New("not an access token")
+	}
+
+	revoked, err := s.store.IsTokenRevoked(claims)
+	if err != nil {
+		log.Printf("Error checking token revocation: %v", err)
+		return nil, err
+	}
+	if revoked {
+		return nil, errors.New("token revoked")
+	}
+	return claims, nil
+}
+
+// apiKeyClaims acts as the owning account, always with the customer role so
+// a key can never reach staff endpoints
+func (s *authService) apiKeyClaims(plain string) (*domain.JWTClaims, error) {
+	key, err := s.apiKeys.Authenticate(plain)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.JWTClaims{
+		Id:     key.AcNumber,
+		Role:   domain.RoleCustomer,
+		KeyId:  key.Id,
+		Scopes: key.ScopeList(),
+	}, nil
+}
+
+// RequireScope lets API keys through only when they hold the scope
+func (s *authService) RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := c.Get("user").(*domain.JWTClaims)
+			if !ok || !claims.HasScope(scope) {
+				return echo.NewHTTPError(http.StatusForbidden, "missing scope "+scope)
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireSession keeps API keys out of routes meant for a logged in customer
+func (s *authService) RequireSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims, ok := c.Get("user").(*domain.JWTClaims)
+		if !ok || claims.IsAPIKey() {
+			return echo.NewHTTPError(http.StatusForbidden, "this endpoint requires a user session")
+		}
+		return next(c)
+	}
+}
+
+// RequireRole must run after Middleware, it only lets through tokens carrying one of the roles
+func (s *authService) RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := c.Get("user").(*domain.JWTClaims)
+			if !ok || !claims.HasRole(roles...) {
+				return echo.ErrForbidden
+			}
+			return next(c)
+		}
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
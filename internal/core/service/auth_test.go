@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+func TestCheckStepUp(t *testing.T) {
+	const threshold = 100000
+	maxAge := 5 * time.Minute
+	s := NewAuthService(nil, nil, nil, time.Minute, time.Hour, threshold, maxAge)
+	authAt := func(ago time.Duration) *jwt.NumericDate {
+		return jwt.NewNumericDate(time.Now().Add(-ago))
+	}
+
+	tests := []struct {
+		name    string
+		claims  *domain.JWTClaims
+		amount  int64
+		wantErr bool
+	}{
+		{"below threshold without auth time", &domain.JWTClaims{}, threshold - 1, false},
+		{"at threshold without auth time", &domain.JWTClaims{}, threshold, false},
+		{"above threshold recent login", &domain.JWTClaims{AuthTime: authAt(time.Minute)}, threshold + 1, false},
+		{"above threshold old login", &domain.JWTClaims{AuthTime: authAt(maxAge + time.Minute)}, threshold + 1, true},
+		{"above threshold without auth time", &domain.JWTClaims{}, threshold + 1, true},
+		{"api key below threshold", &domain.JWTClaims{KeyId: "key", AuthTime: authAt(0)}, threshold, false},
+		// api keys cannot authenticate again so large transfers need a session
+		{"api key above threshold", &domain.JWTClaims{KeyId: "key", AuthTime: authAt(0)}, threshold + 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.CheckStepUp(tt.claims, tt.amount)
+			var stepUp *domain.StepUpRequiredError
+			if tt.wantErr != (err != nil) || (err != nil && !errors.As(err, &stepUp)) {
+				t.Fatalf("CheckStepUp = %v, want error %v", err, tt.wantErr)
+			}
+			if stepUp != nil && stepUp.MaxAge != maxAge {
+				t.Errorf("MaxAge = %s, want %s", stepUp.MaxAge, maxAge)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// SendEmailVerification mails the verification link through the notifier
+func (s *accountService) SendEmailVerification(acc *domain.Account, token string) error {
+	return s.notifier.Notify(domain.Notification{
+		To:      acc.Email,
+		Subject: "Confirm your FastBank email address",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your email address within %d hours to start sending transfers:\n\n%s%s\n\nIf you did not open a FastBank account you can ignore this email.",
+			acc.Fname, int(emailVerificationTTL.Hours()), s.verifyURL, token),
+	})
+}
+
+func (s *accountService) VerifyEmail(accNo int, email string) (*domain.Account, error) {
+	return s.store.ExecuteAccountCommand(accNo, func(acc *domain.Account) (*domain.AccountEvent, error) {
+		return acc.VerifyEmail(email)
+	})
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"log"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+)
+
+// publishEvent emits a domain event, the event stream is best effort and never
+// fails the operation that produced it
+func publishEvent(events port.EventPublisher, eventType string, data any) {
+	if err := events.Publish(domain.NewEvent(eventType, data)); err != nil {
+		log.Printf("Error publishing %s event: %v", eventType, err)
+	}
+}
+
+func transferEventData(msg domain.TransferMessage, reason string) domain.TransferEventData {
+	return domain.TransferEventData{
+		TransferId: msg.TransferId,
+		SenderId:   msg.SenderId,
+		ToAccount:  msg.ToAccount,
+		Amount:     msg.Amount,
+		Status:     msg.Status,
+		Reason:     reason,
+	}
+}
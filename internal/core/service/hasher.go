@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Argon2Params are the argon2id cost parameters, memory is in KiB
+type Argon2Params struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+// argon2Hasher stores hashes in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>, and still verifies the
+// bcrypt hashes written by earlier versions so they can be upgraded on login
+type argon2Hasher struct {
+	params Argon2Params
+}
+
+func NewArgon2Hasher(params Argon2Params) port.PasswordHasher {
+	return &argon2Hasher{params: params}
+}
+
+func (h *argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Time, h.params.Memory, h.params.Threads, h.params.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		h.params.Memory, h.params.Time, h.params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether the password matches and whether the hash should be
+// replaced, because it is bcrypt or was made with other argon2 parameters
+func (h *argon2Hasher) Verify(hash, password string) (bool, bool) {
+	if strings.HasPrefix(hash, "$2") {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil, true
+	}
+
+	params, salt, key, err := decodeArgon2(hash)
+	if err != nil {
+		return false, false
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false
+	}
+	return true, params != h.params
+}
+
+func decodeArgon2(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("not an argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, err
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	params.SaltLen, params.KeyLen = uint32(len(salt)), uint32(len(key))
+	return params, salt, key, nil
+}
+
+// PasswordPolicy checks new passwords before they are hashed
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	breached  map[string]struct{}
+}
+
+// LoadPasswordPolicy reads the breached password list from path, one
+// password per line, an empty path disables the check
+func LoadPasswordPolicy(minLength, maxLength int, path string) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{MinLength: minLength, MaxLength: maxLength, breached: make(map[string]struct{})}
+	if path == "" {
+		return policy, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		policy.breached[strings.ToLower(line)] = struct{}{}
+	}
+	return policy, scanner.Err()
+}
+
+func (p *PasswordPolicy) Check(password string) error {
+	if password == "" {
+		return &domain.PasswordPolicyError{Reason: "password is required"}
+	}
+	// length is counted in characters, not bytes
+	n := len([]rune(password))
+	if n < p.MinLength {
+		return &domain.PasswordPolicyError{Reason: fmt.Sprintf("password needs at least %d characters", p.MinLength)}
+	}
+	if p.MaxLength > 0 && n > p.MaxLength {
+		return &domain.PasswordPolicyError{Reason: fmt.Sprintf("password can have at most %d characters", p.MaxLength)}
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return &domain.PasswordPolicyError{Reason: "password appears in a list of breached passwords, please choose another one"}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// cheap parameters, the tests are about the format and not the cost
+var testArgon2 = Argon2Params{Memory: 64, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32}
+
+func TestArgon2HasherVerify(t *testing.T) {
+	h := NewArgon2Hasher(testArgon2)
+	hash := func(params Argon2Params, password string) string {
+		s, err := NewArgon2Hasher(params).Hash(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := hash(testArgon2, "secret")
+	older := hash(Argon2Params{Memory: 32, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32}, "secret")
+	parts := strings.Split(current, "$")
+
+	tests := []struct {
+		name       string
+		hash       string
+		password   string
+		wantOk     bool
+		wantRehash bool
+	}{
+		{"current parameters", current, "secret", true, false},
+		{"wrong password", current, "Secret", false, false},
+		{"older parameters are upgraded", older, "secret", true, true},
+		{"older parameters wrong password", older, "wrong", false, false},
+		{"bcrypt is upgraded", string(bcryptHash), "secret", true, true},
+		{"bcrypt wrong password", string(bcryptHash), "wrong", false, true},
+		{"argon2i", strings.Replace(current, "$argon2id$", "$argon2i$", 1), "secret", false, false},
+		{"unsupported version", strings.Replace(current, "$v=19$", "$v=16$", 1), "secret", false, false},
+		{"missing parameters", strings.Join([]string{"", parts[1], parts[2], "m=64", parts[4], parts[5]}, "$"), "secret", false, false},
+		{"invalid salt", strings.Join([]string{"", parts[1], parts[2], parts[3], "!!", parts[5]}, "$"), "secret", false, false},
+		{"invalid key", strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], "!!"}, "$"), "secret", false, false},
+		{"too few fields", "$argon2id$v=19$m=64,t=1,p=1$salt", "secret", false, false},
+		{"empty", "", "secret", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash := h.Verify(tt.hash, tt.password)
+			if ok != tt.wantOk || rehash != tt.wantRehash {
+				t.Errorf("Verify = (%v, %v), want (%v, %v)", ok, rehash, tt.wantOk, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestArgon2HasherHash(t *testing.T) {
+	h := NewArgon2Hasher(testArgon2)
+	first, err := h.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := h.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Error("two hashes of the same password share a salt")
+	}
+	if !strings.HasPrefix(first, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("hash %q is not in the PHC format", first)
+	}
+	params, salt, key, err := decodeArgon2(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != testArgon2 || len(salt) != 16 || len(key) != 32 {
+		t.Errorf("decoded %+v with %d byte salt and %d byte key", params, len(salt), len(key))
+	}
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := &PasswordPolicy{MinLength: 8, MaxLength: 12, breached: map[string]struct{}{"password1": {}}}
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"long enough", "correcthorse", false},
+		{"empty", "", true},
+		{"too short", "short", true},
+		{"too long", "correcthorsebattery", true},
+		{"counted in characters", "pässwörtchen", false},
+		{"breached", "password1", true},
+		{"breached in other case", "PassWord1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password)
+			var policyErr *domain.PasswordPolicyError
+			if tt.wantErr != (err != nil) || (err != nil && !errors.As(err, &policyErr)) {
+				t.Errorf("Check(%q) = %v, want error %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
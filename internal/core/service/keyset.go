@@ -0,0 +1,178 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// KeySet holds every key tokens may be verified with and the single key new
+// tokens are signed with, so keys can be rotated by adding the new key first,
+// switching the signing key and dropping the old one once its tokens expired
+type KeySet struct {
+	keys   map[string]*signingKey
+	active *signingKey
+}
+
+// LoadKeySet reads every *.pem file of dir, the file name without extension is
+// the key id. Private keys (PKCS#8 RSA or Ed25519, PKCS#1 RSA) can sign, public
+// keys (PKIX) are only accepted for verification. The signing key is activeKid,
+// or the last private key in name order when activeKid is empty. An empty dir
+// is refused unless allowEphemeral, for local development only.
+func LoadKeySet(dir, activeKid string, allowEphemeral bool) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*signingKey)}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := loadKey(file, kid)
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[kid] = key
+		if key.private != nil && activeKid == "" {
+			ks.active = key
+		}
+	}
+
+	if activeKid != "" {
+		key, ok := ks.keys[activeKid]
+		if !ok || key.private == nil {
+			return nil, fmt.Errorf("signing key %q has no private key in %s", activeKid, dir)
+		}
+		ks.active = key
+	}
+
+	if ks.active == nil {
+		if len(ks.keys) > 0 {
+			return nil, fmt.Errorf("no private key found in %s", dir)
+		}
+		if !allowEphemeral {
+			return nil, fmt.Errorf("no JWT keys found in %s, run make keygen or set JWT_EPHEMERAL_KEY=true for local development", dir)
+		}
+		log.Printf("WARNING: no JWT keys found in %s, signing with an ephemeral Ed25519 key because JWT_EPHEMERAL_KEY is set. Tokens will not survive a restart and other instances reject them, never run like this in production", dir)
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		ks.active = &signingKey{kid: "ephemeral", method: jwt.SigningMethodEdDSA, private: private, public: private.Public()}
+		ks.keys[ks.active.kid] = ks.active
+	}
+	return ks, nil
+}
+
+func loadKey(file, kid string) (*signingKey, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", file)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", file, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	key := &signingKey{kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, k.Public()
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("%s: only RSA and Ed25519 keys are supported", file)
+	}
+	return key, nil
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	token.Header["kid"] = ks.active.kid
+	return token.SignedString(ks.active.private)
+}
+
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("signing method does not match the key")
+	}
+	return key.public, nil
+}
+
+func (ks *KeySet) methods() []string {
+	return []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+}
+
+func (ks *KeySet) JWKS() domain.JWKS {
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := domain.JWKS{Keys: make([]domain.JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		jwk := domain.JWK{Use: "sig", Alg: key.method.Alg(), Kid: kid}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
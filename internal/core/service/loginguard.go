@@ -0,0 +1,126 @@
+package service
+
+import (
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+)
+
+const (
+	// failures older than this no longer count
+	loginFailureWindow = time.Hour
+	// the first failures are free, then every failure doubles the delay
+	freeLoginFailures = 2
+	baseLoginDelay    = time.Second
+	maxLoginDelay     = 30 * time.Second
+	// one IP may try many accounts, e.g. behind a NAT, so it gets more room
+	ipFailureFactor = 10
+)
+
+type loginGuard struct {
+	store       port.LoginAttemptStore
+	maxFailures int
+	lockout     time.Duration
+}
+
+func NewLoginGuard(store port.LoginAttemptStore, maxFailures int, lockout time.Duration) port.LoginGuard {
+	return &loginGuard{store: store, maxFailures: maxFailures, lockout: lockout}
+}
+
+// Check returns a *domain.LoginBlockedError while the account or the IP has
+// to wait before the next attempt
+func (g *loginGuard) Check(accNo int, ip string) error {
+	attempts, err := g.store.GetLoginAttempts(domain.AccountLoginKey(accNo), domain.IPLoginKey(ip))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var blocked *domain.LoginBlockedError
+	for _, attempt := range attempts {
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			wait := attempt.LockedUntil.Sub(now)
+			if blocked == nil || !blocked.Locked || wait > blocked.RetryAfter {
+				blocked = &domain.LoginBlockedError{Locked: true, RetryAfter: wait}
+			}
+			continue
+		}
+		if attempt.NextAttemptAt.After(now) && (blocked == nil || !blocked.Locked) {
+			wait := attempt.NextAttemptAt.Sub(now)
+			if blocked == nil || wait > blocked.RetryAfter {
+				blocked = &domain.LoginBlockedError{RetryAfter: wait}
+			}
+		}
+	}
+	if blocked != nil {
+		return blocked
+	}
+	return nil
+}
+
+// Failed records a failed attempt and reports whether it locked the account
+func (g *loginGuard) Failed(accNo int, ip string) (bool, error) {
+	accountLocked, err := g.fail(domain.AccountLoginKey(accNo), g.maxFailures)
+	if err != nil {
+		return false, err
+	}
+	if _, err := g.fail(domain.IPLoginKey(ip), g.maxFailures*ipFailureFactor); err != nil {
+		return false, err
+	}
+	return accountLocked, nil
+}
+
+func (g *loginGuard) fail(key string, maxFailures int) (bool, error) {
+	now := time.Now().UTC()
+	attempt, err := g.store.RecordLoginFailure(key, now.Add(-loginFailureWindow))
+	if err != nil {
+		return false, err
+	}
+
+	locked := false
+	attempt.NextAttemptAt = now.Add(loginDelay(attempt.Failures))
+	if attempt.Failures >= maxFailures {
+		until := now.Add(g.lockout)
+		attempt.LockedUntil = &until
+		// only the failure crossing the threshold locks, later ones just extend it
+		locked = attempt.Failures == maxFailures
+	}
+	return locked, g.store.UpdateLoginAttempt(attempt)
+}
+
+// Succeeded forgets the failures of the account, the IP counter is kept so
+// a valid login cannot be used to keep guessing other accounts
+func (g *loginGuard) Succeeded(accNo int) error {
+	return g.store.ClearLoginAttempts(domain.AccountLoginKey(accNo))
+}
+
+func (g *loginGuard) Unlock(accNo int) error {
+	return g.store.ClearLoginAttempts(domain.AccountLoginKey(accNo))
+}
+
+// Lockout returns the failure record of the account while it keeps the
+// account from logging in, nil otherwise
+func (g *loginGuard) Lockout(accNo int) (*domain.LoginAttempt, error) {
+	attempts, err := g.store.GetLoginAttempts(domain.AccountLoginKey(accNo))
+	if err != nil || len(attempts) == 0 {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	attempt := attempts[0]
+	if attempt.NextAttemptAt.After(now) || attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+		return attempt, nil
+	}
+	return nil, nil
+}
+
+func loginDelay(failures int) time.Duration {
+	if failures <= freeLoginFailures {
+		return 0
+	}
+	delay := baseLoginDelay << (failures - freeLoginFailures - 1)
+	if delay > maxLoginDelay || delay <= 0 {
+		return maxLoginDelay
+	}
+	return delay
+}
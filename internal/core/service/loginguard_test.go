@@ -0,0 +1,180 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+// memLoginStore keeps login attempts like the login_attempts table does
+type memLoginStore struct {
+	attempts map[string]domain.LoginAttempt
+}
+
+func newMemLoginStore() *memLoginStore {
+	return &memLoginStore{attempts: make(map[string]domain.LoginAttempt)}
+}
+
+func (s *memLoginStore) GetLoginAttempts(keys ...string) ([]*domain.LoginAttempt, error) {
+	var res []*domain.LoginAttempt
+	for _, key := range keys {
+		if attempt, ok := s.attempts[key]; ok {
+			res = append(res, &attempt)
+		}
+	}
+	return res, nil
+}
+
+func (s *memLoginStore) RecordLoginFailure(key string, resetAfter time.Time) (*domain.LoginAttempt, error) {
+	now := time.Now().UTC()
+	attempt, ok := s.attempts[key]
+	if !ok {
+		attempt = domain.LoginAttempt{Key: key, NextAttemptAt: now}
+	}
+	if !ok || attempt.LastFailureAt.Before(resetAfter) {
+		attempt.Failures = 1
+	} else {
+		attempt.Failures++
+	}
+	attempt.LastFailureAt = now
+	s.attempts[key] = attempt
+	return &attempt, nil
+}
+
+func (s *memLoginStore) UpdateLoginAttempt(attempt *domain.LoginAttempt) error {
+	s.attempts[attempt.Key] = *attempt
+	return nil
+}
+
+func (s *memLoginStore) ClearLoginAttempts(key string) error {
+	delete(s.attempts, key)
+	return nil
+}
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{7, 16 * time.Second},
+		{8, maxLoginDelay},
+		{100, maxLoginDelay},
+	}
+	for _, tt := range tests {
+		if got := loginDelay(tt.failures); got != tt.want {
+			t.Errorf("loginDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginGuardFailed(t *testing.T) {
+	const maxFailures = 5
+	tests := []struct {
+		failures    int
+		wantLocked  bool
+		wantBlocked bool
+		wantLockout bool
+	}{
+		{1, false, false, false},
+		{2, false, false, false},
+		{3, false, true, false},
+		{4, false, true, false},
+		// only the failure crossing the threshold reports the lock
+		{5, true, true, true},
+		{6, false, true, true},
+	}
+	store := newMemLoginStore()
+	guard := NewLoginGuard(store, maxFailures, 15*time.Minute)
+	for _, tt := range tests {
+		locked, err := guard.Failed(1, "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if locked != tt.wantLocked {
+			t.Errorf("failure %d: locked = %v, want %v", tt.failures, locked, tt.wantLocked)
+		}
+
+		var blocked *domain.LoginBlockedError
+		err = guard.Check(1, "10.0.0.2")
+		if errors.As(err, &blocked) != tt.wantBlocked {
+			t.Fatalf("failure %d: Check = %v, want blocked %v", tt.failures, err, tt.wantBlocked)
+		}
+		if blocked != nil && blocked.Locked != tt.wantLockout {
+			t.Errorf("failure %d: Locked = %v, want %v", tt.failures, blocked.Locked, tt.wantLockout)
+		}
+		if blocked != nil && tt.wantLockout && blocked.RetryAfter < 14*time.Minute {
+			t.Errorf("failure %d: RetryAfter = %s, want the lockout", tt.failures, blocked.RetryAfter)
+		}
+	}
+}
+
+func TestLoginGuardSucceeded(t *testing.T) {
+	store := newMemLoginStore()
+	guard := NewLoginGuard(store, 5, 15*time.Minute)
+	for i := 0; i < 5; i++ {
+		if _, err := guard.Failed(1, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := guard.Succeeded(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.Check(1, "10.0.0.2"); err != nil {
+		t.Errorf("account still blocked after a successful login: %v", err)
+	}
+	// the IP keeps its failures so a valid login cannot reset guessing
+	if _, ok := store.attempts[domain.IPLoginKey("10.0.0.1")]; !ok {
+		t.Error("a successful login cleared the failures of the IP")
+	}
+}
+
+func TestLoginGuardIPLockout(t *testing.T) {
+	const maxFailures = 3
+	store := newMemLoginStore()
+	guard := NewLoginGuard(store, maxFailures, 15*time.Minute)
+	// every account fails once, only the IP adds up
+	for accNo := 1; accNo <= maxFailures*ipFailureFactor; accNo++ {
+		if _, err := guard.Failed(accNo, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var blocked *domain.LoginBlockedError
+	if err := guard.Check(1000, "10.0.0.1"); !errors.As(err, &blocked) || !blocked.Locked {
+		t.Errorf("Check from the IP = %v, want a lockout", err)
+	}
+	if err := guard.Check(1000, "10.0.0.2"); err != nil {
+		t.Errorf("Check from another IP = %v, want nil", err)
+	}
+}
+
+func TestLoginGuardFailureWindow(t *testing.T) {
+	store := newMemLoginStore()
+	guard := NewLoginGuard(store, 5, 15*time.Minute)
+	for i := 0; i < 4; i++ {
+		if _, err := guard.Failed(1, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// failures older than the window start over
+	key := domain.AccountLoginKey(1)
+	attempt := store.attempts[key]
+	attempt.LastFailureAt = time.Now().UTC().Add(-loginFailureWindow - time.Minute)
+	attempt.NextAttemptAt = attempt.LastFailureAt
+	store.attempts[key] = attempt
+
+	locked, err := guard.Failed(1, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locked || store.attempts[key].Failures != 1 {
+		t.Errorf("failure after the window: locked %v with %d failures, want 1 failure", locked, store.attempts[key].Failures)
+	}
+}
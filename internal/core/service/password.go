@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+)
+
+const resetTokenTTL = 30 * time.Minute
+
+var errPasswordChanged = errors.New("password changed concurrently")
+
+// Authenticate checks the password of an account
+func (s *accountService) Authenticate(accNo int, password string) (*domain.Account, error) {
+	acc, err := s.store.GetAccountByAccNo(accNo)
+	if err != nil || acc.Status == domain.StatusClosed {
+		return nil, domain.ErrInvalidCredentials
+	}
+	ok, rehash := s.hasher.Verify(acc.EPassword, password)
+	if !ok {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if rehash {
+		s.rehashPassword(acc, password)
+	}
+	return acc, nil
+}
+
+// rehashPassword upgrades a bcrypt or outdated argon2 hash while the plain
+// password is at hand, failing to do so must not fail the login
+func (s *accountService) rehashPassword(acc *domain.Account, password string) {
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Printf("Error rehashing password of account %d: %v", acc.AcNumber, err)
+		return
+	}
+	old := acc.EPassword
+	_, err = s.store.ExecuteAccountCommand(int(acc.AcNumber), func(locked *domain.Account) (*domain.AccountEvent, error) {
+		// the password changed in the meantime, the new hash is already current
+		if locked.EPassword != old {
+			return nil, errPasswordChanged
+		}
+		return locked.RehashPassword(hash)
+	})
+	if err != nil && !errors.Is(err, errPasswordChanged) {
+		log.Printf("Error rehashing password of account %d: %v", acc.AcNumber, err)
+		return
+	}
+	acc.EPassword = hash
+}
+
+func (s *accountService) ChangePassword(accNo int, oldPassword, newPassword string) error {
+	if _, err := s.Authenticate(accNo, oldPassword); err != nil {
+		return err
+	}
+	return s.setPassword(accNo, newPassword)
+}
+
+// RequestPasswordReset sends a single use reset token to the account email,
+// unknown accounts are silently ignored so the endpoint cannot be used to
+// probe for account numbers
+func (s *accountService) RequestPasswordReset(accNo int) error {
+	acc, err := s.store.GetAccountByAccNo(accNo)
+	if err != nil {
+		return nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	err = s.resets.SaveResetToken(&domain.PasswordResetToken{
+		Id:        uuid.NewString(),
+		AcNumber:  accNo,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().UTC().Add(resetTokenTTL),
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.notifier.Notify(domain.Notification{
+		To:      acc.Email,
+		Subject: "Reset your FastBank password",
+		Body: fmt.Sprintf("Hi %s,\n\nuse the link below within %d minutes to choose a new password:\n\n%s%s\n\nIf you did not ask for this you can ignore this email.",
+			acc.Fname, int(resetTokenTTL.Minutes()), s.resetURL, token),
+	})
+	if err != nil {
+		log.Printf("Error sending password reset to account %d: %v", accNo, err)
+	}
+	return nil
+}
+
+// ResetPassword redeems a reset token and returns the account it belongs to
+func (s *accountService) ResetPassword(token, newPassword string) (int, error) {
+	if err := s.policy.Check(newPassword); err != nil {
+		return 0, err
+	}
+	reset, err := s.resets.UseResetToken(hashToken(token))
+	if err != nil {
+		return 0, err
+	}
+	if reset == nil {
+		return 0, domain.ErrInvalidResetToken
+	}
+	return reset.AcNumber, s.setPassword(reset.AcNumber, newPassword)
+}
+
+func (s *accountService) setPassword(accNo int, password string) error {
+	if err := s.policy.Check(password); err != nil {
+		return err
+	}
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.ExecuteAccountCommand(accNo, func(acc *domain.Account) (*domain.AccountEvent, error) {
+		return acc.ChangePassword(hash)
+	})
+	return err
+}
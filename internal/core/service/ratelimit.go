@@ -0,0 +1,98 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+)
+
+// expired counters are deleted at most this often
+const rateLimitSweepInterval = time.Minute
+
+type rateLimiter struct {
+	store port.RateLimitStore
+
+	mu        sync.Mutex
+	maxWindow time.Duration
+	lastSweep time.Time
+}
+
+func NewRateLimiter(store port.RateLimitStore) port.RateLimiter {
+	return &rateLimiter{store: store}
+}
+
+// Limit counts requests in fixed windows per caller, the account of a session,
+// the API key or the client IP on routes without authentication. It only sees
+// the caller after the auth middleware, before it every request counts for the
+// client IP. Responses carry the RateLimit
+// headers of draft-ietf-httpapi-ratelimit-headers, a caller over the limit
+// gets a *domain.RateLimitedError.
+func (l *rateLimiter) Limit(limit domain.RateLimit) echo.MiddlewareFunc {
+	l.mu.Lock()
+	l.maxWindow = max(l.maxWindow, limit.Window)
+	l.mu.Unlock()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			now := time.Now().UTC()
+			windowStart := now.Truncate(limit.Window)
+			reset := windowStart.Add(limit.Window).Sub(now)
+			l.sweep(now)
+
+			count, err := l.store.HitRateLimit(limit.Name+"|"+rateLimitKey(c), windowStart)
+			if err != nil {
+				// an unavailable store must not take the API down with it
+				log.Printf("WARNING: rate limit %s is not enforced, counting the request failed: %v", limit.Name, err)
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(limit.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(max(limit.Limit-count, 0)))
+			header.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Limit, int(limit.Window.Seconds())))
+			if count > limit.Limit {
+				return &domain.RateLimitedError{RetryAfter: reset}
+			}
+			return next(c)
+		}
+	}
+}
+
+func rateLimitKey(c echo.Context) string {
+	claims, ok := c.Get("user").(*domain.JWTClaims)
+	switch {
+	case !ok:
+		return "ip:" + c.RealIP()
+	case claims.IsAPIKey():
+		return "key:" + claims.KeyId
+	default:
+		return "acc:" + strconv.Itoa(claims.Id)
+	}
+}
+
+// sweep deletes counters of windows that ended, in the background so no
+// request waits for it
+func (l *rateLimiter) sweep(now time.Time) {
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		l.mu.Unlock()
+		return
+	}
+	l.lastSweep = now
+	before := now.Add(-l.maxWindow)
+	l.mu.Unlock()
+
+	go func() {
+		if err := l.store.SweepRateLimits(before); err != nil {
+			log.Printf("Error sweeping rate limit counters: %v", err)
+		}
+	}()
+}
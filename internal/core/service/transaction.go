@@ -2,8 +2,12 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/sarthak014/Fast-Bank/internal/core/domain"
 	"github.com/sarthak014/Fast-Bank/internal/core/port"
@@ -16,14 +20,20 @@ type transactionService struct {
 	store     port.StorageService
 	rabbitMQ  *amqp.Connection
 	queueName string
+	hub       *statusHub
+	events    port.EventPublisher
+	audit     port.AuditService
 }
 
-func NewTransactionService(store port.StorageService, conn *amqp.Connection) port.TransactionService {
+func NewTransactionService(store port.StorageService, conn *amqp.Connection, events port.EventPublisher, audit port.AuditService) port.TransactionService {
 
 	return &transactionService{
 		store:     store,
 		rabbitMQ:  conn,
+		events:    events,
+		audit:     audit,
 		queueName: "transfers",
+		hub:       newStatusHub(),
 	}
 }
 
@@ -62,8 +72,50 @@ func (s *transactionService) PublishTransferMessage(msg domain.TransferMessage)
 			ContentType: "application/json",
 			Body:        body,
 		})
+	if err != nil {
+		return err
+	}
 
-	return err
+	publishEvent(s.events, domain.EventTransferRequested, transferEventData(msg, ""))
+	return nil
+}
+
+// CreateTransfer queues a transfer after the checks that can be made before
+// it is executed, the balance is only checked when it runs
+func (s *transactionService) CreateTransfer(senderId, toId int, amount int64) (*domain.TransferMessage, error) {
+	if err := domain.ValidateTransfer(senderId, toId, amount); err != nil {
+		return nil, err
+	}
+	sender, err := s.store.GetAccountByAccNo(senderId)
+	if err != nil {
+		return nil, err
+	}
+	if err := sender.CanSendTransfers(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	msg := &domain.TransferMessage{
+		TransferId: uuid.NewString(),
+		SenderId:   senderId,
+		ToAccount:  toId,
+		Amount:     amount,
+		Status:     domain.TransferPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	// the record has to exist before a worker can pick the message up and
+	// set its status
+	if err := s.AddTransferRecord(msg); err != nil {
+		return nil, fmt.Errorf("failed to add transfer: %w", err)
+	}
+	if err := s.PublishTransferMessage(*msg); err != nil {
+		if er := s.setStatus(*msg, domain.TransferFailed, "internal_error"); er != nil {
+			log.Printf("Error failing unqueued transfer %s: %v", msg.TransferId, er)
+		}
+		return nil, fmt.Errorf("failed to initiate transfer: %w", err)
+	}
+	return msg, nil
 }
 
 func (s *transactionService) AddTransferRecord(msg *domain.TransferMessage) error {
@@ -128,47 +180,70 @@ func (s *transactionService) ProcessTransfers() {
 	<-forever
 }
 
-func (s *transactionService) GetByAccNo(accNo int) ([]*domain.TransferMessage, error) {
-	return s.store.GetTransactionsByAccNo(accNo)
+func (s *transactionService) List(filter domain.TransferFilter) ([]*domain.TransferMessage, string, error) {
+	return s.store.GetTransfers(filter)
+}
+
+func (s *transactionService) History(filter domain.HistoryFilter) ([]*domain.HistoryEntry, string, error) {
+	return s.store.GetHistory(filter)
 }
 
 func (s *transactionService) ExecuteTransfer(msg domain.TransferMessage) error {
-	senderAccount, err := s.store.GetAccountByAccNo(msg.SenderId)
-	if err != nil {
-		er := s.store.UpdateTransferStatus(msg.TransferId, "failed")
-		if er != nil {
+	if err := s.transfer(&msg); err != nil {
+		if er := s.setStatus(msg, domain.TransferFailed, failureReason(err)); er != nil {
 			return er
 		}
-		return fmt.Errorf("failed to retrieve sender account: %v", err)
+		return err
 	}
 
-	if senderAccount.Balance < msg.Amount {
-		er := s.store.UpdateTransferStatus(msg.TransferId, "failed")
-		if er != nil {
-			return er
-		}
-		return fmt.Errorf("insufficient balance in sender account")
+	// update trx log
+	return s.setStatus(msg, domain.TransferCompleted, "")
+}
+
+// failureReason turns the error a transfer failed with into the stable code
+// clients see, the same as the API error, anything unexpected stays in the log
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInsufficientBalance):
+		return "insufficient_funds"
+	case errors.Is(err, domain.ErrAccountFrozen):
+		return "account_frozen"
+	case errors.Is(err, domain.ErrAccountDebitBlocked):
+		return "account_debit_blocked"
+	case errors.Is(err, domain.ErrAccountClosed):
+		return "account_closed"
+	case errors.Is(err, domain.ErrNotFound):
+		return "account_not_found"
+	case errors.Is(err, domain.ErrValidation):
+		return "validation_failed"
+	default:
+		return "internal_error"
 	}
+}
 
-	recipientAccount, err := s.store.GetAccountByAccNo(msg.ToAccount)
+func (s *transactionService) transfer(msg *domain.TransferMessage) error {
+	if err := domain.ValidateTransfer(msg.SenderId, msg.ToAccount, msg.Amount); err != nil {
+		return err
+	}
+	senderAccount, err := s.store.GetAccountByAccNo(msg.SenderId)
 	if err != nil {
-		er := s.store.UpdateTransferStatus(msg.TransferId, "failed")
-		if er != nil {
-			return er
-		}
-		return fmt.Errorf("failed to retrieve recipient account: %v", err)
+		return fmt.Errorf("failed to retrieve sender account: %w", err)
 	}
-	// proper trx
-	if err := s.store.Transcation(senderAccount, recipientAccount, &msg); err != nil {
-		return err
+
+	if senderAccount.Balance < msg.Amount {
+		return domain.ErrInsufficientBalance
 	}
 
-	// update trx log
-	err = s.store.UpdateTransferStatus(msg.TransferId, "completed")
+	recipientAccount, err := s.store.GetAccountByAccNo(msg.ToAccount)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to retrieve recipient account: %w", err)
 	}
-	return nil
+	// proper trx
+	return s.store.Transcation(senderAccount, recipientAccount, msg)
+}
+
+func (s *transactionService) GetTransfer(trxid string) (*domain.TransferMessage, error) {
+	return s.store.GetTransfer(trxid)
 }
 
 func (s *transactionService) GetTransferStatus(trxid string) (string, error) {
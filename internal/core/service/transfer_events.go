@@ -0,0 +1,202 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/streadway/amqp"
+)
+
+// transfer status changes are fanned out to every API instance through this
+// exchange, each instance then hands them to its own local subscribers
+const transferStatusExchange = "transfer.status"
+
+type statusHub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan domain.TransferStatusEvent]struct{}
+}
+
+func newStatusHub() *statusHub {
+	return &statusHub{subs: make(map[string]map[chan domain.TransferStatusEvent]struct{})}
+}
+
+func (h *statusHub) subscribe(trxid string) (<-chan domain.TransferStatusEvent, func()) {
+	ch := make(chan domain.TransferStatusEvent, 4)
+
+	h.mu.Lock()
+	if h.subs[trxid] == nil {
+		h.subs[trxid] = make(map[chan domain.TransferStatusEvent]struct{})
+	}
+	h.subs[trxid][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[trxid], ch)
+			if len(h.subs[trxid]) == 0 {
+				delete(h.subs, trxid)
+			}
+			h.mu.Unlock()
+		})
+	}
+}
+
+func (h *statusHub) broadcast(evt domain.TransferStatusEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[evt.TransferId] {
+		select {
+		case ch <- evt:
+			continue
+		default:
+		}
+		// a slow subscriber can miss intermediate states but never the final
+		// one, it is what ends its stream. Only the hub sends, so once the oldest
+		// buffered event is discarded the final event fits.
+		if !evt.Final() {
+			continue
+		}
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+func (s *transactionService) WatchTransfer(trxid string) (<-chan domain.TransferStatusEvent, func()) {
+	return s.hub.subscribe(trxid)
+}
+
+// setStatus persists the new status of a transfer and announces it to all instances
+// as well as on the domain event stream
+func (s *transactionService) setStatus(msg domain.TransferMessage, status, reason string) error {
+	if err := s.store.UpdateTransferStatus(msg.TransferId, status); err != nil {
+		return err
+	}
+	evt := domain.TransferStatusEvent{
+		TransferId: msg.TransferId,
+		Status:     status,
+		Reason:     reason,
+		UpdatedAt:  time.Now().UTC(),
+	}
+	if err := s.publishStatus(evt); err != nil {
+		log.Printf("Error publishing transfer status: %v", err)
+	}
+
+	s.audit.Record(domain.AuditEntry{
+		ActorId:    domain.SystemActor,
+		Action:     domain.AuditTransferStatusChanged,
+		Resource:   "transfer",
+		ResourceId: msg.TransferId,
+	}, map[string]string{"status": msg.Status}, map[string]string{"status": status, "reason": reason})
+
+	msg.Status = status
+	switch status {
+	case domain.TransferCompleted:
+		publishEvent(s.events, domain.EventTransferCompleted, transferEventData(msg, ""))
+	case domain.TransferFailed:
+		publishEvent(s.events, domain.EventTransferFailed, transferEventData(msg, reason))
+	}
+	return nil
+}
+
+func (s *transactionService) publishStatus(evt domain.TransferStatusEvent) error {
+	ch, err := s.rabbitMQ.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if err := declareStatusExchange(ch); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	return ch.Publish(
+		transferStatusExchange, // exchange
+		"",                     // routing key
+		false,                  // mandatory
+		false,                  // immediate
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		})
+}
+
+func (s *transactionService) ListenTransferStatus() {
+	ch, err := s.rabbitMQ.Channel()
+	if err != nil {
+		log.Fatalf("Failed to open a channel: %v", err)
+	}
+	defer ch.Close()
+
+	if err := declareStatusExchange(ch); err != nil {
+		log.Fatalf("Failed to declare an exchange: %v", err)
+	}
+
+	q, err := ch.QueueDeclare(
+		"",    // name
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		log.Fatalf("Failed to declare a queue: %v", err)
+	}
+
+	if err := ch.QueueBind(q.Name, "", transferStatusExchange, false, nil); err != nil {
+		log.Fatalf("Failed to bind a queue: %v", err)
+	}
+
+	msgs, err := ch.Consume(
+		q.Name, // queue
+		"",     // consumer
+		true,   // auto-ack
+		true,   // exclusive
+		false,  // no-local
+		false,  // no-wait
+		nil,    // args
+	)
+	if err != nil {
+		log.Fatalf("Failed to register a consumer: %v", err)
+	}
+
+	for d := range msgs {
+		var evt domain.TransferStatusEvent
+		if err := json.Unmarshal(d.Body, &evt); err != nil {
+			log.Printf("Error decoding status event: %v", err)
+			continue
+		}
+		s.hub.broadcast(evt)
+	}
+	// without the consumer no instance hears about status changes anymore,
+	// stop like a failed setup and let the supervisor restart us
+	log.Fatalf("Transfer status consumer stopped, the AMQP channel was closed")
+}
+
+func declareStatusExchange(ch *amqp.Channel) error {
+	return ch.ExchangeDeclare(
+		transferStatusExchange, // name
+		"fanout",               // type
+		true,                   // durable
+		false,                  // auto-deleted
+		false,                  // internal
+		false,                  // no-wait
+		nil,                    // arguments
+	)
+}
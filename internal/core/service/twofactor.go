@@ -0,0 +1,144 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sarthak014/Fast-Bank/internal/core/domain"
+	"github.com/sarthak014/Fast-Bank/internal/core/port"
+	"github.com/sarthak014/Fast-Bank/pkg/utils"
+)
+
+const (
+	totpIssuer        = "FastBank"
+	recoveryCodeCount = 10
+)
+
+type twoFactorService struct {
+	store port.TwoFactorStore
+}
+
+func NewTwoFactorService(store port.TwoFactorStore) port.TwoFactorService {
+	return &twoFactorService{store: store}
+}
+
+// Enroll starts or restarts an enrollment, 2FA stays off until Activate
+func (s *twoFactorService) Enroll(accNo int) (*domain.TOTPEnrollment, error) {
+	current, err := s.store.GetTwoFactor(accNo)
+	if err == nil && current.Enabled {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	err = s.store.SaveTwoFactor(&domain.TwoFactor{
+		AcNumber:  accNo,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TOTPEnrollment{
+		Secret:     secret,
+		OtpauthURI: utils.TOTPURI(totpIssuer, strconv.Itoa(accNo), secret),
+	}, nil
+}
+
+// Activate turns 2FA on once the customer proved their app generates valid
+// codes and returns the one-time recovery codes, they are never shown again
+func (s *twoFactorService) Activate(accNo int, code string) ([]string, error) {
+	tf, err := s.store.GetTwoFactor(accNo)
+	if err != nil {
+		return nil, domain.ErrNoEnrollment
+	}
+	if tf.Enabled {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+	step, ok := utils.ValidateTOTP(tf.Secret, code, time.Now())
+	if !ok {
+		return nil, domain.ErrInvalidCode
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]*domain.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, &domain.RecoveryCode{
+			AcNumber:  accNo,
+			CodeHash:  hashToken(normalizeRecoveryCode(code)),
+			CreatedAt: time.Now().UTC(),
+		})
+	}
+	if err := s.store.EnableTwoFactor(accNo, step, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) Enabled(accNo int) (bool, error) {
+	tf, err := s.store.GetTwoFactor(accNo)
+	if errors.Is(err, domain.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled, nil
+}
+
+// Verify accepts a current TOTP code or an unused recovery code
+func (s *twoFactorService) Verify(accNo int, code string) error {
+	tf, err := s.store.GetTwoFactor(accNo)
+	if err != nil || !tf.Enabled {
+		return domain.ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := utils.ValidateTOTP(tf.Secret, code, time.Now()); ok {
+		fresh, err := s.store.UseTOTPStep(accNo, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return domain.ErrInvalidCode
+		}
+		return nil
+	}
+
+	used, err := s.store.UseRecoveryCode(accNo, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return domain.ErrInvalidCode
+	}
+	return nil
+}
+
+// newRecoveryCode returns a code like "k3vq7-mz2xa" that is easy to write down
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}